@@ -0,0 +1,175 @@
+// Command prctl is an admin tool for routine operations on teams, users and
+// pull requests. It talks to the database through the service layer, using
+// the same configuration as the server.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/Wucop228/avito-PullRequest/internal/app"
+	"github.com/Wucop228/avito-PullRequest/internal/config"
+	"github.com/Wucop228/avito-PullRequest/internal/events"
+	"github.com/Wucop228/avito-PullRequest/internal/service"
+)
+
+const usage = `usage: prctl [-o table|json] <command> [flags]
+
+commands:
+  team create -f FILE           create teams from a YAML, JSON or CSV file
+  team get -team NAME           show a team with its members
+  user set-active -user ID -active=BOOL
+                                activate or deactivate a user
+  user reviews -user ID         list pull requests the user reviews
+  user token -user ID           issue an API token the user declines reviews with
+  user revoke-tokens -user ID   revoke all API tokens of the user
+  pr reassign -pr ID -old ID [-to ID]
+                                replace a reviewer with a random teammate or a given user
+  pr add-reviewer -pr ID -user ID
+                                add a reviewer to an open pull request
+  pr remove-reviewer -pr ID -user ID
+                                remove a reviewer without a replacement
+  pr top-up -pr ID -reviewers N add teammates until the pull request has N reviewers
+  pr merge -pr ID               mark a pull request as merged
+  org import -f FILE [-dry-run] create or update teams and users from an org file
+  org export [-format F]        print all teams and users as yaml, json or csv
+  org plan -f FILE [-reassign-reviews]
+                                show how the database differs from the desired org
+  org apply -f FILE [-reassign-reviews]
+                                make the database match the desired org
+  stats                         show pull request counts and reviewer load
+
+Run "prctl <command> <subcommand> -h" for the flags of a command.`
+
+var errUsage = errors.New("invalid usage")
+
+type cli struct {
+	// ctx is cancelled on Ctrl-C.
+	ctx   context.Context
+	out   *printer
+	teams *service.TeamService
+	users *service.UserService
+	prs   *service.PullRequestService
+	stats *service.StatsService
+	org   *service.OrgService
+}
+
+func main() {
+	flag.Usage = func() { fmt.Fprintln(os.Stderr, usage) }
+	output := flag.String("o", formatTable, "output format: table or json")
+	flag.Parse()
+
+	out, err := newPrinter(*output, os.Stdout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "prctl: %v\n", err)
+		os.Exit(2)
+	}
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "prctl: failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	db, err := app.NewDB(cfg.DB)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "prctl: failed to connect to database: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	// Changes made from the CLI show up in the /events stream of the server.
+	prs := service.NewPullRequestService(db, events.NewStoreSink(db, nil), service.AssignmentConfig{
+		Reviewers:       cfg.Assignment.Reviewers,
+		Seed:            cfg.Assignment.Seed,
+		ReplacementPool: service.ReplacementPool(cfg.Assignment.ReplacementPool),
+		DeclineQuota:    cfg.Assignment.DeclineQuota,
+	})
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	c := &cli{
+		ctx:   ctx,
+		out:   out,
+		teams: service.NewTeamService(db),
+		users: service.NewUserService(db),
+		prs:   prs,
+		stats: service.NewStatsService(db),
+		org:   service.NewOrgService(db, prs),
+	}
+
+	if err := c.run(flag.Args()); err != nil {
+		if errors.Is(err, errUsage) || errors.Is(err, flag.ErrHelp) {
+			flag.Usage()
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "prctl: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func (c *cli) run(args []string) error {
+	command, args := args[0], args[1:]
+
+	if command == "stats" {
+		return c.showStats(args)
+	}
+
+	if len(args) == 0 {
+		return errUsage
+	}
+	sub, args := args[0], args[1:]
+
+	switch command + " " + sub {
+	case "team create":
+		return c.createTeams(args)
+	case "team get":
+		return c.getTeam(args)
+	case "user set-active":
+		return c.setUserActive(args)
+	case "user reviews":
+		return c.listUserReviews(args)
+	case "user token":
+		return c.issueUserToken(args)
+	case "user revoke-tokens":
+		return c.revokeUserTokens(args)
+	case "pr reassign":
+		return c.reassignReviewer(args)
+	case "pr add-reviewer":
+		return c.addReviewer(args)
+	case "pr remove-reviewer":
+		return c.removeReviewer(args)
+	case "pr top-up":
+		return c.topUpReviewers(args)
+	case "pr merge":
+		return c.mergePullRequest(args)
+	case "org import":
+		return c.importOrg(args)
+	case "org export":
+		return c.exportOrg(args)
+	case "org plan":
+		return c.reconcileOrg("org plan", false, args)
+	case "org apply":
+		return c.reconcileOrg("org apply", true, args)
+	}
+
+	return errUsage
+}
+
+// requireFlags reports which of the named string flags were left empty.
+func requireFlags(fs *flag.FlagSet, names ...string) error {
+	for _, name := range names {
+		if fs.Lookup(name).Value.String() == "" {
+			return fmt.Errorf("-%s is required", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+	"github.com/Wucop228/avito-PullRequest/internal/orgfile"
+	"github.com/Wucop228/avito-PullRequest/internal/service"
+)
+
+func (c *cli) importOrg(args []string) error {
+	fs := flag.NewFlagSet("org import", flag.ContinueOnError)
+	file := fs.String("f", "", "YAML, JSON or CSV org file")
+	dryRun := fs.Bool("dry-run", false, "only print the changes")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "f"); err != nil {
+		return err
+	}
+
+	teams, err := readOrgFile(*file)
+	if err != nil {
+		return err
+	}
+
+	diff, err := c.org.Import(c.ctx, teams, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	return c.printOrgDiff(diff)
+}
+
+func (c *cli) reconcileOrg(name string, apply bool, args []string) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	file := fs.String("f", "", "YAML, JSON or CSV file with the desired org")
+	reassign := fs.Bool("reassign-reviews", false, "hand open reviews of deactivated users over to teammates")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "f"); err != nil {
+		return err
+	}
+
+	teams, err := readOrgFile(*file)
+	if err != nil {
+		return err
+	}
+
+	diff, err := c.org.Reconcile(c.ctx, teams, service.ReconcileOptions{
+		Apply:           apply,
+		ReassignReviews: *reassign,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.printOrgDiff(diff)
+}
+
+func (c *cli) printOrgDiff(diff *models.OrgDiff) error {
+	if c.out.format == formatJSON {
+		return c.out.print(diff, nil, nil)
+	}
+
+	if len(diff.Changes) == 0 {
+		fmt.Fprintln(c.out.w, "no changes")
+	} else {
+		rows := make([][]string, 0, len(diff.Changes))
+		for _, ch := range diff.Changes {
+			active := ""
+			if ch.UserID != "" {
+				active = strconv.FormatBool(ch.IsActive)
+			}
+			rows = append(rows, []string{ch.Action, ch.TeamName, ch.UserID, ch.Username, active, strings.Join(ch.Details, "; ")})
+		}
+		if err := c.out.print(diff, []string{"ACTION", "TEAM", "USER_ID", "USERNAME", "ACTIVE", "DETAILS"}, rows); err != nil {
+			return err
+		}
+	}
+
+	if len(diff.Reassignments) > 0 {
+		fmt.Fprintln(c.out.w)
+		rows := make([][]string, 0, len(diff.Reassignments))
+		for _, r := range diff.Reassignments {
+			rows = append(rows, []string{r.PullRequestID, r.OldUserID, r.NewUserID, r.Error})
+		}
+		if err := c.out.print(diff, []string{"PR", "OLD_REVIEWER", "NEW_REVIEWER", "ERROR"}, rows); err != nil {
+			return err
+		}
+	}
+
+	if diff.DryRun {
+		fmt.Fprintln(c.out.w, "\ndry run, nothing was changed")
+	}
+
+	return nil
+}
+
+func readOrgFile(path string) ([]models.RequestTeamAdd, error) {
+	format, err := orgfile.FormatFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	teams, err := orgfile.Read(f, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return teams, nil
+}
+
+func (c *cli) exportOrg(args []string) error {
+	fs := flag.NewFlagSet("org export", flag.ContinueOnError)
+	format := fs.String("format", orgfile.FormatYAML, "yaml, json or csv")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	teams, err := c.org.Export(c.ctx)
+	if err != nil {
+		return err
+	}
+
+	return orgfile.Write(c.out.w, *format, teams)
+}
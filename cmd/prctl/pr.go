@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"strings"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+)
+
+func (c *cli) reassignReviewer(args []string) error {
+	fs := flag.NewFlagSet("pr reassign", flag.ContinueOnError)
+	prID := fs.String("pr", "", "pull request id")
+	oldUserID := fs.String("old", "", "id of the reviewer to replace")
+	newUserID := fs.String("to", "", "id of the new reviewer, instead of a random teammate")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "pr", "old"); err != nil {
+		return err
+	}
+
+	var (
+		pr         *models.PullRequest
+		replacedBy = *newUserID
+		err        error
+	)
+	if *newUserID != "" {
+		pr, _, err = c.prs.ReassignReviewerTo(c.ctx, *prID, *oldUserID, *newUserID)
+	} else {
+		pr, replacedBy, err = c.prs.ReassignReviewer(c.ctx, *prID, *oldUserID)
+	}
+	if err != nil {
+		return err
+	}
+
+	return c.out.print(
+		struct {
+			PR         *models.PullRequest `json:"pr"`
+			ReplacedBy string              `json:"replaced_by"`
+		}{pr, replacedBy},
+		[]string{"PR", "STATUS", "REVIEWERS", "REPLACED_BY"},
+		[][]string{{pr.PullRequestID, pr.Status, strings.Join(pr.AssignedReviewers, ","), replacedBy}},
+	)
+}
+
+func (c *cli) addReviewer(args []string) error {
+	return c.changeReviewer("pr add-reviewer", c.prs.AddReviewer, args)
+}
+
+func (c *cli) removeReviewer(args []string) error {
+	return c.changeReviewer("pr remove-reviewer", c.prs.RemoveReviewer, args)
+}
+
+func (c *cli) changeReviewer(name string, change func(ctx context.Context, prID, userID string) (*models.PullRequest, *models.AssignmentExplanation, error), args []string) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	prID := fs.String("pr", "", "pull request id")
+	userID := fs.String("user", "", "reviewer id")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "pr", "user"); err != nil {
+		return err
+	}
+
+	pr, _, err := change(c.ctx, *prID, *userID)
+	if err != nil {
+		return err
+	}
+
+	return c.out.print(pr,
+		[]string{"PR", "STATUS", "REVIEWERS"},
+		[][]string{{pr.PullRequestID, pr.Status, strings.Join(pr.AssignedReviewers, ",")}},
+	)
+}
+
+func (c *cli) topUpReviewers(args []string) error {
+	fs := flag.NewFlagSet("pr top-up", flag.ContinueOnError)
+	prID := fs.String("pr", "", "pull request id")
+	count := fs.Int("reviewers", 0, "how many reviewers the pull request should have")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "pr"); err != nil {
+		return err
+	}
+
+	pr, added, _, err := c.prs.TopUpReviewers(c.ctx, *prID, *count)
+	if err != nil {
+		return err
+	}
+
+	return c.out.print(
+		struct {
+			PR    *models.PullRequest `json:"pr"`
+			Added []string            `json:"added"`
+		}{pr, added},
+		[]string{"PR", "STATUS", "REVIEWERS", "ADDED"},
+		[][]string{{pr.PullRequestID, pr.Status, strings.Join(pr.AssignedReviewers, ","), strings.Join(added, ",")}},
+	)
+}
+
+func (c *cli) mergePullRequest(args []string) error {
+	fs := flag.NewFlagSet("pr merge", flag.ContinueOnError)
+	prID := fs.String("pr", "", "pull request id")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "pr"); err != nil {
+		return err
+	}
+
+	pr, err := c.prs.MergePullRequest(c.ctx, *prID)
+	if err != nil {
+		return err
+	}
+
+	return c.out.print(pr,
+		[]string{"PR", "NAME", "AUTHOR", "STATUS", "REVIEWERS"},
+		[][]string{{pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.Status, strings.Join(pr.AssignedReviewers, ",")}},
+	)
+}
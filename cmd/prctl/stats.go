@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+)
+
+func (c *cli) showStats(args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	stats, err := c.stats.GetReviewStats(c.ctx)
+	if err != nil {
+		return err
+	}
+
+	if c.out.format == formatJSON {
+		return c.out.print(stats, nil, nil)
+	}
+
+	counts := stats.PullRequests
+	fmt.Fprintf(c.out.w, "pull requests: %d open, %d merged, %d closed\n\n", counts.Open, counts.Merged, counts.Closed)
+
+	rows := make([][]string, 0, len(stats.Reviewers))
+	for _, r := range stats.Reviewers {
+		rows = append(rows, []string{
+			r.UserID,
+			r.Username,
+			r.TeamName,
+			strconv.FormatBool(r.IsActive),
+			strconv.Itoa(r.OpenReviews),
+			strconv.Itoa(r.TotalReviews),
+		})
+	}
+
+	return c.out.print(stats, []string{"USER_ID", "USERNAME", "TEAM", "ACTIVE", "OPEN", "TOTAL"}, rows)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/Wucop228/avito-PullRequest/internal/service"
+)
+
+func (c *cli) createTeams(args []string) error {
+	fs := flag.NewFlagSet("team create", flag.ContinueOnError)
+	file := fs.String("f", "", "YAML, JSON or CSV file with teams")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "f"); err != nil {
+		return err
+	}
+
+	teams, err := readOrgFile(*file)
+	if err != nil {
+		return err
+	}
+
+	type result struct {
+		TeamName string `json:"team_name"`
+		Members  int    `json:"members"`
+		Result   string `json:"result"`
+	}
+
+	results := make([]result, 0, len(teams))
+	rows := make([][]string, 0, len(teams))
+	var failed error
+
+	for i := range teams {
+		team := &teams[i]
+		res := result{TeamName: team.TeamName, Members: len(team.Members), Result: "created"}
+
+		if err := c.teams.CreateTeamWithMembers(c.ctx, team); err != nil {
+			if !errors.Is(err, service.ErrTeamExists) {
+				return fmt.Errorf("team %s: %w", team.TeamName, err)
+			}
+			res.Result = "exists"
+			failed = fmt.Errorf("some teams already exist")
+		}
+
+		results = append(results, res)
+		rows = append(rows, []string{res.TeamName, strconv.Itoa(res.Members), res.Result})
+	}
+
+	if err := c.out.print(results, []string{"TEAM", "MEMBERS", "RESULT"}, rows); err != nil {
+		return err
+	}
+
+	return failed
+}
+
+func (c *cli) getTeam(args []string) error {
+	fs := flag.NewFlagSet("team get", flag.ContinueOnError)
+	name := fs.String("team", "", "team name")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "team"); err != nil {
+		return err
+	}
+
+	team, err := c.teams.GetTeam(c.ctx, *name)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(team.Members))
+	for _, m := range team.Members {
+		rows = append(rows, []string{m.UserID, m.Username, strconv.FormatBool(m.IsActive)})
+	}
+
+	return c.out.print(team, []string{"USER_ID", "USERNAME", "ACTIVE"}, rows)
+}
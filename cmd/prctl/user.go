@@ -0,0 +1,101 @@
+package main
+
+import (
+	"flag"
+	"strconv"
+)
+
+func (c *cli) setUserActive(args []string) error {
+	fs := flag.NewFlagSet("user set-active", flag.ContinueOnError)
+	userID := fs.String("user", "", "user id")
+	active := fs.Bool("active", true, "whether the user can be assigned reviews")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "user"); err != nil {
+		return err
+	}
+
+	user, err := c.users.SetIsActive(c.ctx, *userID, *active)
+	if err != nil {
+		return err
+	}
+
+	return c.out.print(user,
+		[]string{"USER_ID", "USERNAME", "TEAM", "ACTIVE"},
+		[][]string{{user.UserID, user.Username, user.TeamName, strconv.FormatBool(user.IsActive)}},
+	)
+}
+
+func (c *cli) listUserReviews(args []string) error {
+	fs := flag.NewFlagSet("user reviews", flag.ContinueOnError)
+	userID := fs.String("user", "", "user id")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "user"); err != nil {
+		return err
+	}
+
+	prs, err := c.prs.GetUserReviews(c.ctx, *userID)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(prs))
+	for _, pr := range prs {
+		rows = append(rows, []string{pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.Status})
+	}
+
+	return c.out.print(prs, []string{"PR", "NAME", "AUTHOR", "STATUS"}, rows)
+}
+
+func (c *cli) issueUserToken(args []string) error {
+	fs := flag.NewFlagSet("user token", flag.ContinueOnError)
+	userID := fs.String("user", "", "user id")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "user"); err != nil {
+		return err
+	}
+
+	token, err := c.users.IssueToken(c.ctx, *userID)
+	if err != nil {
+		return err
+	}
+
+	return c.out.print(
+		struct {
+			UserID string `json:"user_id"`
+			Token  string `json:"token"`
+		}{*userID, token},
+		[]string{"USER_ID", "TOKEN"},
+		[][]string{{*userID, token}},
+	)
+}
+
+func (c *cli) revokeUserTokens(args []string) error {
+	fs := flag.NewFlagSet("user revoke-tokens", flag.ContinueOnError)
+	userID := fs.String("user", "", "user id")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "user"); err != nil {
+		return err
+	}
+
+	n, err := c.users.RevokeTokens(c.ctx, *userID)
+	if err != nil {
+		return err
+	}
+
+	return c.out.print(
+		struct {
+			UserID  string `json:"user_id"`
+			Revoked int64  `json:"revoked"`
+		}{*userID, n},
+		[]string{"USER_ID", "REVOKED"},
+		[][]string{{*userID, strconv.FormatInt(n, 10)}},
+	)
+}
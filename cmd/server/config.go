@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Wucop228/avito-PullRequest/internal/config"
+)
+
+const configUsage = `usage: server config <command>
+
+commands:
+  print        print the effective config with secrets redacted and report
+               invalid settings`
+
+var errConfigUsage = errors.New(configUsage)
+
+// runConfig works on an unvalidated config, so that print can show what is
+// wrong with it.
+func runConfig(args []string) error {
+	if len(args) != 1 || args[0] != "print" {
+		return errConfigUsage
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	if err := printConfig(os.Stdout, cfg); err != nil {
+		return err
+	}
+
+	return cfg.Validate()
+}
+
+func printConfig(w io.Writer, cfg *config.Config) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(cfg.Redacted()); err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+	return enc.Close()
+}
@@ -2,51 +2,100 @@ package main
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"log/slog"
+	"os"
 	"os/signal"
 	"syscall"
-	"time"
+	// Team timezones must resolve in the alpine image, which has no tzdata.
+	_ "time/tzdata"
 
 	"github.com/Wucop228/avito-PullRequest/internal/app"
 	"github.com/Wucop228/avito-PullRequest/internal/config"
+	"github.com/Wucop228/avito-PullRequest/internal/logger"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		if err := runConfig(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		log.Fatalf("failed to load config: %v", err)
+		// The logger is not set up yet, and the list of invalid settings
+		// reads better as is.
+		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	l, err := logger.New(os.Stderr, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		fatal("failed to init logger", err)
+	}
+	// Also routes the standard log package, used by libraries, through l.
+	slog.SetDefault(l)
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			if err := runMigrate(cfg, os.Args[2:]); err != nil {
+				fatal("migrate failed", err)
+			}
+			return
+		default:
+			fatal("unknown command, expected migrate or config", fmt.Errorf("command %q", os.Args[1]))
+		}
 	}
 
 	application, err := app.NewApp(cfg)
 	if err != nil {
-		log.Fatalf("failed to init app: %v", err)
+		fatal("failed to init app", err)
 	}
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	errCh := make(chan error, 1)
+	application.StartScheduler(ctx)
+
+	errCh := make(chan error, 2)
 	go func() {
 		if err := application.RunHTTP(); err != nil {
 			errCh <- err
 		}
 	}()
+	go func() {
+		if err := application.RunGRPC(); err != nil {
+			errCh <- fmt.Errorf("grpc: %w", err)
+		}
+	}()
 
 	select {
 	case <-ctx.Done():
-		log.Printf("shutdown signal received, shutting down gracefully")
+		slog.Info("shutdown signal received, shutting down gracefully")
 	case err := <-errCh:
 		if err != nil {
-			log.Printf("server error: %v", err)
+			slog.Error("server error", "error", err)
 		}
 	}
 
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// Stops the scheduler when the server exits on its own.
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := application.Shutdown(shutdownCtx); err != nil {
-		log.Printf("graceful shutdown failed: %v", err)
+		slog.Error("graceful shutdown failed", "error", err)
 	} else {
-		log.Println("server stopped gracefully")
+		slog.Info("server stopped gracefully")
 	}
 }
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
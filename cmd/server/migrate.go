@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/Wucop228/avito-PullRequest/internal/app"
+	"github.com/Wucop228/avito-PullRequest/internal/config"
+	"github.com/Wucop228/avito-PullRequest/internal/migrator"
+)
+
+const migrateUsage = `usage: server migrate <command>
+
+commands:
+  up           apply all pending migrations
+  down [n]     roll back n migrations (default 1)
+  steps n      apply n migrations, negative n rolls back
+  goto v       migrate up or down to version v
+  version      print the current version
+  force v      set the version without migrating and clear the dirty flag`
+
+var errUsage = errors.New(migrateUsage)
+
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	db, err := app.NewDB(cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	m, err := migrator.New(db)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	switch args[0] {
+	case "up":
+		err = m.Up()
+	case "down":
+		n := 1
+		if len(args) > 1 {
+			if n, err = positiveArg(args[1]); err != nil {
+				return err
+			}
+		}
+		err = m.Down(n)
+	case "steps":
+		if len(args) < 2 {
+			return errUsage
+		}
+		n, convErr := strconv.Atoi(args[1])
+		if convErr != nil {
+			return fmt.Errorf("invalid steps %q", args[1])
+		}
+		err = m.Steps(n)
+	case "goto":
+		if len(args) < 2 {
+			return errUsage
+		}
+		v, convErr := positiveArg(args[1])
+		if convErr != nil {
+			return convErr
+		}
+		err = m.Goto(uint(v))
+	case "force":
+		if len(args) < 2 {
+			return errUsage
+		}
+		v, convErr := strconv.Atoi(args[1])
+		if convErr != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		err = m.Force(v)
+	case "version":
+	default:
+		return errUsage
+	}
+	if err != nil {
+		return err
+	}
+
+	version, dirty, err := m.Version()
+	if err != nil {
+		return err
+	}
+	slog.Info("database schema version", "version", version, "dirty", dirty)
+
+	return nil
+}
+
+func positiveArg(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("expected a positive number, got %q", s)
+	}
+	return n, nil
+}
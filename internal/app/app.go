@@ -3,64 +3,236 @@ package app
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
+	"time"
 
+	"github.com/XSAM/otelsql"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
 
 	_ "github.com/lib/pq"
 
+	"github.com/Wucop228/avito-PullRequest/api"
 	"github.com/Wucop228/avito-PullRequest/internal/config"
+	graphqldelivery "github.com/Wucop228/avito-PullRequest/internal/delivery/graphql"
+	grpcdelivery "github.com/Wucop228/avito-PullRequest/internal/delivery/grpc"
 	httpdelivery "github.com/Wucop228/avito-PullRequest/internal/delivery/http"
+	"github.com/Wucop228/avito-PullRequest/internal/events"
+	"github.com/Wucop228/avito-PullRequest/internal/migrator"
+	"github.com/Wucop228/avito-PullRequest/internal/ratelimit"
+	"github.com/Wucop228/avito-PullRequest/internal/repo"
+	"github.com/Wucop228/avito-PullRequest/internal/scheduler"
 	"github.com/Wucop228/avito-PullRequest/internal/service"
+	"github.com/Wucop228/avito-PullRequest/internal/tracing"
 )
 
+// eventsPollInterval is how often the event log is checked for events
+// written by other replicas.
+const eventsPollInterval = time.Second
+
 type App struct {
-	cfg  *config.Config
-	db   *sql.DB
-	echo *echo.Echo
+	cfg       *config.Config
+	db        *sql.DB
+	echo      *echo.Echo
+	grpc      *grpc.Server
+	scheduler *scheduler.Scheduler
+	broker    *events.Broker
+
+	shutdownTracing func(context.Context) error
+	schedulerDone   chan struct{}
 }
 
 func NewApp(cfg *config.Config) (*App, error) {
-	db, err := newDB(cfg.DB)
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    cfg.Tracing.Exporter,
+		ServiceName: cfg.Tracing.ServiceName,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("set up tracing: %w", err)
+	}
+
+	db, err := NewDB(cfg.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.DB.MigrateOnStart {
+		if err := migrateUp(db); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("migrate on start: %w", err)
+		}
+	}
+
+	spec, err := api.LoadSpec()
 	if err != nil {
+		db.Close()
 		return nil, err
 	}
+	validator, err := httpdelivery.OpenAPIValidator(spec, cfg.Server.ValidateRequests, cfg.Server.ValidateResponses)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("build openapi validator: %w", err)
+	}
 
 	e := echo.New()
 	e.HideBanner = true
 	e.HidePort = true
+	e.Server.ReadTimeout = cfg.Server.ReadTimeout
+	e.Server.WriteTimeout = cfg.Server.WriteTimeout
+	e.Server.IdleTimeout = cfg.Server.IdleTimeout
+	if cfg.Server.TrustProxy {
+		e.IPExtractor = echo.ExtractIPFromXFFHeader()
+	} else {
+		e.IPExtractor = echo.ExtractIPDirect()
+	}
+
+	idempotencySvc := service.NewIdempotencyService(db, cfg.Server.IdempotencyTTL)
+
+	// Goes first so that the request span covers the other middleware and
+	// their log lines carry its trace id.
+	e.Use(otelecho.Middleware(cfg.Tracing.ServiceName))
+	e.Use(httpdelivery.RequestID())
+	e.Use(httpdelivery.RequestLogger())
+	e.Use(httpdelivery.Recover())
+	if cfg.RateLimit.Enabled {
+		rateLimit, err := httpdelivery.RateLimit(spec, newRateLimiter(cfg.RateLimit, db))
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		e.Use(rateLimit)
+	}
+	e.Use(middleware.BodyLimit(cfg.Server.BodyLimit))
+	e.Use(validator)
+	e.Use(httpdelivery.Idempotency(idempotencySvc))
+
+	broker := events.NewBroker(db, eventsPollInterval)
+	if err := broker.Start(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("start event broker: %w", err)
+	}
 
-	e.Use(middleware.Recover())
-	e.Use(middleware.Logger())
+	sink := events.MultiSink{events.LogSink{}, events.NewStoreSink(db, broker.Notify)}
+	if cfg.Events.WebhookURL != "" {
+		// The webhook is optional: only the stored log must have the event.
+		sink = append(sink, events.BestEffortSink{Sink: events.NewWebhookSink(cfg.Events.WebhookURL)})
+	}
 
 	teamSvc := service.NewTeamService(db)
 	userSvc := service.NewUserService(db)
-	prSvc := service.NewPullRequestService(db)
+	prSvc := service.NewPullRequestService(db, sink, service.AssignmentConfig{
+		Reviewers:       cfg.Assignment.Reviewers,
+		Seed:            cfg.Assignment.Seed,
+		ReplacementPool: service.ReplacementPool(cfg.Assignment.ReplacementPool),
+		DeclineQuota:    cfg.Assignment.DeclineQuota,
+	})
+	githubSvc := service.NewGitHubService(db, prSvc)
+	gitlabSvc := service.NewGitLabService(db, prSvc)
+	orgSvc := service.NewOrgService(db, prSvc)
+	statsSvc := service.NewStatsService(db)
+	eventSvc := service.NewEventService(db, broker)
+	querySvc := service.NewQueryService(db)
 
 	teamHandler := httpdelivery.NewTeamHandler(teamSvc)
 	userHandler := httpdelivery.NewUserHandler(userSvc)
 	prHandler := httpdelivery.NewPullRequestHandler(prSvc)
+	reviewerHandler := httpdelivery.NewReviewerHandler(userSvc, prSvc)
+	githubHandler := httpdelivery.NewGitHubHandler(githubSvc, cfg.Auth.GitHubWebhookSecret)
+	gitlabHandler := httpdelivery.NewGitLabHandler(gitlabSvc, cfg.Auth.GitLabWebhookToken)
+	orgHandler := httpdelivery.NewOrgHandler(orgSvc)
+	statsHandler := httpdelivery.NewStatsHandler(statsSvc)
+	metricsHandler := httpdelivery.NewMetricsHandler(statsSvc)
+	eventsHandler := httpdelivery.NewEventsHandler(eventSvc)
+	graphqlHandler, err := graphqldelivery.NewHandler(querySvc)
+	if err != nil {
+		broker.Close()
+		db.Close()
+		return nil, fmt.Errorf("build graphql schema: %w", err)
+	}
+
+	if err := httpdelivery.RegisterRoutes(e, spec, map[string]echo.HandlerFunc{
+		"teamAdd":           teamHandler.TeamAdd,
+		"teamGet":           teamHandler.TeamGet,
+		"teamSetSettings":   teamHandler.SetSettings,
+		"teamGetSettings":   teamHandler.GetSettings,
+		"teamSetCodeOwners": teamHandler.SetCodeOwners,
+		"teamGetCodeOwners": teamHandler.GetCodeOwners,
+
+		"orgImport":    orgHandler.Import,
+		"orgExport":    orgHandler.Export,
+		"orgReconcile": orgHandler.Reconcile,
+
+		"usersSetIsActive":      userHandler.SetIsActive,
+		"usersSetExternalLogin": userHandler.SetExternalLogin,
+		"usersGetReview":        prHandler.GetUserReviews,
+
+		"pullRequestCreate":         prHandler.Create,
+		"pullRequestGet":            prHandler.Get,
+		"pullRequestMerge":          prHandler.Merge,
+		"pullRequestReassign":       prHandler.Reassign,
+		"pullRequestAddReviewer":    prHandler.AddReviewer,
+		"pullRequestRemoveReviewer": prHandler.RemoveReviewer,
+		"pullRequestTopUp":          prHandler.TopUp,
+		"pullRequestDecline":        reviewerHandler.Decline,
+
+		"statsPullRequests": statsHandler.PullRequests,
+		"statsReviewSLA":    statsHandler.ReviewSLA,
+		"metrics":           metricsHandler.Metrics,
 
-	e.POST("/team/add", teamHandler.TeamAdd)
-	e.GET("/team/get", teamHandler.TeamGet)
+		"eventsStream": eventsHandler.Stream,
 
-	e.POST("/users/setIsActive", userHandler.SetIsActive)
-	e.GET("/users/getReview", prHandler.GetUserReviews)
+		"graphqlGet":  graphqlHandler.Query,
+		"graphqlPost": graphqlHandler.Query,
 
-	e.POST("/pullRequest/create", prHandler.Create)
-	e.POST("/pullRequest/merge", prHandler.Merge)
-	e.POST("/pullRequest/reassign", prHandler.Reassign)
+		"githubWebhook": githubHandler.Webhook,
+		"gitlabWebhook": gitlabHandler.Webhook,
+	}); err != nil {
+		broker.Close()
+		db.Close()
+		return nil, fmt.Errorf("register routes: %w", err)
+	}
+
+	sched := scheduler.New(db, prSvc, sink, scheduler.Config{
+		Interval:         cfg.Scheduler.Interval,
+		StalePRThreshold: cfg.Scheduler.StalePRThreshold,
+		ReassignAfter:    cfg.Scheduler.ReassignAfter,
+		EventRetention:   cfg.Events.Retention,
+	})
 
 	return &App{
-		cfg:  cfg,
-		db:   db,
-		echo: e,
+		cfg:       cfg,
+		db:        db,
+		echo:      e,
+		grpc:      grpcdelivery.NewServer(teamSvc, userSvc, prSvc),
+		scheduler: sched,
+		broker:    broker,
+
+		shutdownTracing: shutdownTracing,
 	}, nil
 }
 
-func newDB(cfg config.DBConfig) (*sql.DB, error) {
+func newRateLimiter(cfg config.RateLimitConfig, db *sql.DB) *ratelimit.Limiter {
+	var store ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.Store == "postgres" {
+		store = ratelimit.NewPostgresStore(db)
+	}
+
+	routes := make(map[string]ratelimit.Limit, len(cfg.Routes))
+	for name, l := range cfg.Routes {
+		routes[name] = ratelimit.Limit(l)
+	}
+	return ratelimit.New(store, ratelimit.Limit(cfg.Default), routes)
+}
+
+func NewDB(cfg config.DBConfig) (*sql.DB, error) {
 	connStr := fmt.Sprintf(
 		"postgres://%s:%s@%s:%s/%s?sslmode=%s",
 		cfg.User,
@@ -71,36 +243,166 @@ func newDB(cfg config.DBConfig) (*sql.DB, error) {
 		cfg.SSLMode,
 	)
 
-	db, err := sql.Open("postgres", connStr)
+	// Statements get spans only inside a traced operation, so the event
+	// broker polling the log does not start a trace every second.
+	db, err := otelsql.Open("postgres", connStr,
+		otelsql.WithAttributes(semconv.DBSystemNamePostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitRows:             true,
+			SpanFilter: func(ctx context.Context, _ otelsql.Method, _ string, _ []driver.NamedValue) bool {
+				return trace.SpanFromContext(ctx).SpanContext().IsValid()
+			},
+		}),
+	)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := db.Ping(); err != nil {
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	if err := waitForDB(db, cfg.ConnectWait); err != nil {
 		db.Close()
 		return nil, err
 	}
 
-	log.Println("connected to database")
+	repo.SetRetryPolicy(repo.RetryPolicy{
+		Attempts: cfg.RetryAttempts,
+		Backoff:  cfg.RetryBackoff,
+	})
+
+	slog.Info("connected to database", "host", cfg.Host, "name", cfg.Name)
 	return db, nil
 }
 
+const (
+	connectBackoff    = 250 * time.Millisecond
+	maxConnectBackoff = 5 * time.Second
+	pingTimeout       = 5 * time.Second
+)
+
+// waitForDB pings the database until it answers or wait runs out, backing
+// off between attempts, so that the server can start together with Postgres.
+func waitForDB(db *sql.DB, wait time.Duration) error {
+	deadline := time.Now().Add(wait)
+	backoff := connectBackoff
+
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+		err := db.PingContext(ctx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+
+		left := time.Until(deadline)
+		if left <= 0 {
+			return fmt.Errorf("database is not reachable (attempts: %d): %w", attempt, err)
+		}
+		delay := min(backoff, left)
+		slog.Warn("database is not reachable yet", "attempt", attempt, "retry_in", delay, "error", err)
+
+		time.Sleep(delay)
+		backoff = min(backoff*2, maxConnectBackoff)
+	}
+}
+
+func migrateUp(db *sql.DB) error {
+	m, err := migrator.New(db)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil {
+		return err
+	}
+
+	version, _, err := m.Version()
+	if err != nil {
+		return err
+	}
+	slog.Info("database schema is up to date", "version", version)
+	return nil
+}
+
 func (a *App) RunHTTP() error {
 	addr := ":" + a.cfg.Server.Port
-	log.Printf("starting HTTP server on %s", addr)
+	slog.Info("starting HTTP server", "addr", addr)
 	return a.echo.Start(addr)
 }
 
+// RunGRPC serves the gRPC API until Shutdown. It returns nil right away when
+// GRPC_PORT is empty.
+func (a *App) RunGRPC() error {
+	if a.cfg.Server.GRPCPort == "" {
+		return nil
+	}
+
+	addr := ":" + a.cfg.Server.GRPCPort
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("starting gRPC server", "addr", addr)
+	return a.grpc.Serve(lis)
+}
+
+// StartScheduler runs the background jobs until ctx is cancelled. It does
+// nothing when the scheduler is disabled.
+func (a *App) StartScheduler(ctx context.Context) {
+	if !a.cfg.Scheduler.Enabled {
+		return
+	}
+
+	a.schedulerDone = make(chan struct{})
+	go func() {
+		defer close(a.schedulerDone)
+		a.scheduler.Run(ctx)
+	}()
+	slog.Info("scheduler started", "interval", a.cfg.Scheduler.Interval)
+}
+
 func (a *App) Shutdown(ctx context.Context) error {
+	// Ends open /events streams; the HTTP server would otherwise wait for
+	// them until ctx expires.
+	a.broker.Close()
+
 	if err := a.echo.Shutdown(ctx); err != nil {
 		return err
 	}
 
+	stopped := make(chan struct{})
+	go func() {
+		a.grpc.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		a.grpc.Stop()
+		return ctx.Err()
+	}
+
+	// The scheduler stops with the context passed to StartScheduler; wait
+	// for the current tick before closing the database.
+	if a.schedulerDone != nil {
+		select {
+		case <-a.schedulerDone:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
 	if a.db != nil {
 		if err := a.db.Close(); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	return a.shutdownTracing(ctx)
 }
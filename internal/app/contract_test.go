@@ -0,0 +1,371 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+
+	"github.com/Wucop228/avito-PullRequest/api"
+	"github.com/Wucop228/avito-PullRequest/internal/config"
+	"github.com/Wucop228/avito-PullRequest/internal/service"
+)
+
+const (
+	contractGitHubSecret = "contract-github-secret"
+	contractGitLabToken  = "contract-gitlab-token"
+)
+
+// contractClient sends requests straight to the echo instance of the app and
+// remembers which operations of the spec it has called.
+type contractClient struct {
+	t      *testing.T
+	app    *App
+	router routers.Router
+	called map[string]bool
+}
+
+type contractRequest struct {
+	method      string
+	target      string
+	contentType string
+	body        []byte
+	header      map[string]string
+	ctx         context.Context
+}
+
+func (c *contractClient) do(r contractRequest, wantStatus int) []byte {
+	c.t.Helper()
+
+	req := httptest.NewRequest(r.method, r.target, bytes.NewReader(r.body))
+	if r.ctx != nil {
+		req = req.WithContext(r.ctx)
+	}
+	if r.contentType != "" {
+		req.Header.Set("Content-Type", r.contentType)
+	}
+	for k, v := range r.header {
+		req.Header.Set(k, v)
+	}
+
+	route, _, err := c.router.FindRoute(req)
+	if err != nil {
+		c.t.Fatalf("%s %s is not in the spec: %v", r.method, r.target, err)
+	}
+	c.called[route.Operation.OperationID] = true
+
+	rec := httptest.NewRecorder()
+	c.app.echo.ServeHTTP(rec, req)
+
+	body, _ := io.ReadAll(rec.Body)
+	if rec.Code != wantStatus {
+		c.t.Errorf("%s %s: status %d, want %d: %s", r.method, r.target, rec.Code, wantStatus, body)
+	}
+	return body
+}
+
+func (c *contractClient) get(target string, wantStatus int) []byte {
+	c.t.Helper()
+	return c.do(contractRequest{method: http.MethodGet, target: target}, wantStatus)
+}
+
+func (c *contractClient) post(target string, body any, wantStatus int) []byte {
+	c.t.Helper()
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return c.do(contractRequest{
+		method:      http.MethodPost,
+		target:      target,
+		contentType: "application/json",
+		body:        data,
+	}, wantStatus)
+}
+
+// TestContract calls every operation of api/openapi.yml against a real
+// database with response validation on, so a handler that answers with a
+// status or body the spec does not describe fails the test. It needs the
+// DB_* variables of the server and is skipped without them. The data it
+// creates is prefixed with ct- and left in place.
+func TestContract(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Skipf("database is not configured: %v", err)
+	}
+	cfg.DB.MigrateOnStart = true
+	cfg.Server.ValidateRequests = true
+	cfg.Server.ValidateResponses = true
+	cfg.Auth.GitHubWebhookSecret = contractGitHubSecret
+	cfg.Auth.GitLabWebhookToken = contractGitLabToken
+	cfg.Events.WebhookURL = ""
+	// The test sends requests faster than any client should.
+	cfg.RateLimit.Enabled = false
+
+	a, err := NewApp(cfg)
+	if err != nil {
+		t.Fatalf("new app: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := a.Shutdown(ctx); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	}()
+
+	spec, err := api.LoadSpec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	router, err := gorillamux.NewRouter(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &contractClient{t: t, app: a, router: router, called: make(map[string]bool)}
+
+	prefix := "ct-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	team := prefix
+	u1, u2, u3, u4 := prefix+"-u1", prefix+"-u2", prefix+"-u3", prefix+"-u4"
+	prID := prefix + "-pr1"
+	members := []map[string]any{
+		{"user_id": u1, "username": "Alice", "is_active": true},
+		{"user_id": u2, "username": "Bob", "is_active": true},
+		{"user_id": u3, "username": "Carol", "is_active": true},
+		{"user_id": u4, "username": "Dave", "is_active": true},
+	}
+	teamQuery := "?team_name=" + url.QueryEscape(team)
+
+	// Teams.
+	c.post("/team/add", map[string]any{"team_name": team, "members": members}, http.StatusCreated)
+	c.post("/team/add", map[string]any{"team_name": team, "members": members}, http.StatusBadRequest)
+	c.post("/team/add", map[string]any{"members": members}, http.StatusBadRequest)
+	c.get("/team/get"+teamQuery, http.StatusOK)
+	c.get("/team/get?team_name="+prefix+"-missing", http.StatusNotFound)
+	c.get("/team/get", http.StatusBadRequest)
+
+	c.post("/team/setSettings", map[string]any{
+		"team_name":                team,
+		"stale_pr_threshold_hours": 48,
+		"review_sla_hours":         8,
+		"work_days":                []int{1, 2, 3, 4, 5},
+		"timezone":                 "Europe/Moscow",
+	}, http.StatusOK)
+	c.post("/team/setSettings", map[string]any{"team_name": team, "timezone": "Nowhere/Nothing"}, http.StatusBadRequest)
+	c.get("/team/getSettings"+teamQuery, http.StatusOK)
+
+	c.post("/team/setCodeOwners", map[string]any{
+		"team_name": team,
+		"rules":     []map[string]any{{"pattern": "*.go", "owners": []string{u2}}},
+	}, http.StatusOK)
+	c.get("/team/getCodeOwners"+teamQuery, http.StatusOK)
+
+	// Users.
+	c.post("/users/setExternalLogin", map[string]any{"user_id": u1, "provider": "github", "login": prefix}, http.StatusOK)
+	c.post("/users/setIsActive", map[string]any{"user_id": prefix + "-missing", "is_active": false}, http.StatusNotFound)
+
+	// Pull requests.
+	body := c.post("/pullRequest/create", map[string]any{
+		"pull_request_id":   prID,
+		"pull_request_name": "Contract test",
+		"author_id":         u1,
+	}, http.StatusCreated)
+	c.post("/pullRequest/create", map[string]any{
+		"pull_request_id":   prID,
+		"pull_request_name": "Contract test",
+		"author_id":         u1,
+	}, http.StatusConflict)
+	c.post("/pullRequest/create", map[string]any{"pull_request_id": prefix + "-pr2"}, http.StatusBadRequest)
+
+	var created struct {
+		PR struct {
+			AssignedReviewers []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+	}
+	if err := json.Unmarshal(body, &created); err != nil {
+		t.Fatalf("decode created PR: %v", err)
+	}
+	if len(created.PR.AssignedReviewers) != 2 {
+		t.Fatalf("created PR has reviewers %v, want two", created.PR.AssignedReviewers)
+	}
+
+	c.get("/users/getReview?user_id="+url.QueryEscape(created.PR.AssignedReviewers[0]), http.StatusOK)
+
+	// Three teammates besides the author leave one candidate for the swap.
+	c.post("/pullRequest/reassign?explain=true", map[string]any{
+		"pull_request_id": prID,
+		"old_user_id":     created.PR.AssignedReviewers[0],
+	}, http.StatusOK)
+	c.post("/pullRequest/reassign", map[string]any{
+		"pull_request_id": prID,
+		"old_user_id":     created.PR.AssignedReviewers[0],
+	}, http.StatusConflict)
+
+	// Manual changes. The reviewers are now the replacement and the second
+	// one picked on create; the first one picked is the only teammate left
+	// until the decline below swaps them.
+	kept, spare := created.PR.AssignedReviewers[1], created.PR.AssignedReviewers[0]
+	c.post("/pullRequest/removeReviewer?explain=true", map[string]any{"pull_request_id": prID, "user_id": kept}, http.StatusOK)
+	c.post("/pullRequest/removeReviewer", map[string]any{"pull_request_id": prID, "user_id": kept}, http.StatusConflict)
+	c.post("/pullRequest/removeReviewer", map[string]any{"pull_request_id": prefix + "-missing", "user_id": kept}, http.StatusNotFound)
+	c.post("/pullRequest/addReviewer", map[string]any{"pull_request_id": prID, "user_id": u1}, http.StatusConflict)
+	c.post("/pullRequest/addReviewer", map[string]any{"pull_request_id": prID, "user_id": prefix + "-missing"}, http.StatusNotFound)
+	c.post("/pullRequest/addReviewer?explain=true", map[string]any{"pull_request_id": prID, "user_id": kept}, http.StatusOK)
+
+	// The reviewer hands the review over to the only teammate left.
+	token, err := service.NewUserService(a.db).IssueToken(context.Background(), kept)
+	if err != nil {
+		t.Fatalf("issue token: %v", err)
+	}
+	decline := func(token string, body map[string]any, wantStatus int) {
+		t.Helper()
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.do(contractRequest{
+			method:      http.MethodPost,
+			target:      "/pullRequest/decline",
+			contentType: "application/json",
+			body:        data,
+			header:      map[string]string{"Authorization": "Bearer " + token},
+		}, wantStatus)
+	}
+	decline("", map[string]any{"pull_request_id": prID, "reason": "busy"}, http.StatusUnauthorized)
+	decline("prt_unknown", map[string]any{"pull_request_id": prID, "reason": "busy"}, http.StatusUnauthorized)
+	decline(token, map[string]any{"pull_request_id": prID}, http.StatusBadRequest)
+	decline(token, map[string]any{"pull_request_id": prefix + "-missing", "reason": "busy"}, http.StatusNotFound)
+	decline(token, map[string]any{"pull_request_id": prID, "reason": "busy"}, http.StatusOK)
+	decline(token, map[string]any{"pull_request_id": prID, "reason": "busy"}, http.StatusConflict)
+
+	c.post("/pullRequest/topUp?explain=true", map[string]any{"pull_request_id": prID, "reviewers": 3}, http.StatusOK)
+	c.post("/pullRequest/topUp", map[string]any{"pull_request_id": prID, "reviewers": 4}, http.StatusConflict)
+	c.post("/pullRequest/topUp", map[string]any{"pull_request_id": prID, "reviewers": 0}, http.StatusBadRequest)
+	c.post("/pullRequest/reassign?explain=true", map[string]any{
+		"pull_request_id": prID,
+		"old_user_id":     spare,
+		"new_user_id":     u1,
+	}, http.StatusConflict)
+	c.post("/pullRequest/reassign", map[string]any{"pull_request_id": prID, "new_user_id": u1}, http.StatusConflict)
+	c.post("/pullRequest/reassign", map[string]any{"pull_request_id": prID}, http.StatusBadRequest)
+
+	c.get("/pullRequest/get?explain=true&pull_request_id="+url.QueryEscape(prID), http.StatusOK)
+	c.get("/pullRequest/get?pull_request_id="+prefix+"-missing", http.StatusNotFound)
+	c.get("/pullRequest/get", http.StatusBadRequest)
+
+	c.post("/pullRequest/merge", map[string]any{"pull_request_id": prID}, http.StatusOK)
+	c.post("/pullRequest/merge", map[string]any{"pull_request_id": prID}, http.StatusOK)
+	c.post("/pullRequest/merge", map[string]any{"pull_request_id": prefix + "-missing"}, http.StatusNotFound)
+	c.post("/pullRequest/addReviewer", map[string]any{"pull_request_id": prID, "user_id": spare}, http.StatusConflict)
+	c.post("/pullRequest/reassign", map[string]any{
+		"pull_request_id": prID,
+		"old_user_id":     created.PR.AssignedReviewers[1],
+	}, http.StatusConflict)
+
+	c.post("/users/setIsActive", map[string]any{"user_id": u4, "is_active": false}, http.StatusOK)
+
+	// Stats.
+	c.get("/stats/pullRequests", http.StatusOK)
+	c.get("/stats/pullRequests?from=yesterday", http.StatusBadRequest)
+	c.get("/stats/reviewSLA"+teamQuery, http.StatusOK)
+	c.get("/stats/reviewSLA?team_name="+prefix+"-missing", http.StatusNotFound)
+	c.get("/metrics", http.StatusOK)
+
+	// Org. Nothing is applied: the desired state lists only this team, so
+	// applying it would deactivate everybody else.
+	org := map[string]any{"teams": []map[string]any{{"team_name": team, "members": members}}}
+	c.get("/org/export", http.StatusOK)
+	c.get("/org/export?format=csv", http.StatusOK)
+	c.get("/org/export?format=xml", http.StatusBadRequest)
+	c.post("/org/import?dry_run=true", org, http.StatusOK)
+	c.do(contractRequest{
+		method:      http.MethodPost,
+		target:      "/org/import?dry_run=true",
+		contentType: "text/csv",
+		body:        []byte("team_name,user_id,username,is_active\n" + team + "," + u1 + ",Alice,true\n"),
+	}, http.StatusOK)
+	c.do(contractRequest{
+		method:      http.MethodPost,
+		target:      "/org/reconcile",
+		contentType: "application/yaml",
+		body:        []byte("teams:\n  - team_name: " + team + "\n    members:\n      - user_id: " + u1 + "\n        username: Alice\n        is_active: true\n"),
+	}, http.StatusOK)
+
+	// Events: the stream replays the log and ends with the request context.
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	c.do(contractRequest{method: http.MethodGet, target: "/events" + teamQuery + "&last_event_id=0", ctx: ctx}, http.StatusOK)
+	c.get("/events?last_event_id=-1", http.StatusBadRequest)
+
+	// GraphQL.
+	query := `query ($team: String!) { team(name: $team) { name members { id username reviews { id status } } } }`
+	c.get("/graphql?query="+url.QueryEscape(query)+"&variables="+url.QueryEscape(`{"team":"`+team+`"}`), http.StatusOK)
+	c.get("/graphql", http.StatusBadRequest)
+	c.post("/graphql", map[string]any{"query": query, "variables": map[string]any{"team": team}}, http.StatusOK)
+
+	// Webhooks: events that are not about merge requests are acknowledged
+	// and ignored.
+	payload := []byte(`{"zen":"Keep it logically awesome."}`)
+	mac := hmac.New(sha256.New, []byte(contractGitHubSecret))
+	mac.Write(payload)
+	c.do(contractRequest{
+		method:      http.MethodPost,
+		target:      "/webhooks/github",
+		contentType: "application/json",
+		body:        payload,
+		header: map[string]string{
+			"X-GitHub-Event":      "ping",
+			"X-Hub-Signature-256": "sha256=" + hex.EncodeToString(mac.Sum(nil)),
+		},
+	}, http.StatusOK)
+	c.do(contractRequest{
+		method:      http.MethodPost,
+		target:      "/webhooks/github",
+		contentType: "application/json",
+		body:        payload,
+		header:      map[string]string{"X-GitHub-Event": "ping", "X-Hub-Signature-256": "sha256=00"},
+	}, http.StatusUnauthorized)
+	c.do(contractRequest{
+		method:      http.MethodPost,
+		target:      "/webhooks/gitlab",
+		contentType: "application/json",
+		body:        []byte(`{"object_kind":"push"}`),
+		header:      map[string]string{"X-Gitlab-Event": "Push Hook", "X-Gitlab-Token": contractGitLabToken},
+	}, http.StatusOK)
+	c.do(contractRequest{
+		method:      http.MethodPost,
+		target:      "/webhooks/gitlab",
+		contentType: "application/json",
+		body:        []byte(`{"object_kind":"push"}`),
+		header:      map[string]string{"X-Gitlab-Event": "Push Hook", "X-Gitlab-Token": "wrong"},
+	}, http.StatusUnauthorized)
+
+	var missing []string
+	for _, path := range spec.Paths.InMatchingOrder() {
+		for method, op := range spec.Paths.Value(path).Operations() {
+			if !c.called[op.OperationID] {
+				missing = append(missing, op.OperationID+" ("+method+" "+path+")")
+			}
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		t.Errorf("operations not covered by the contract test: %s", strings.Join(missing, ", "))
+	}
+}
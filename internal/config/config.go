@@ -1,60 +1,303 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
 )
 
 type DBConfig struct {
-	User     string
-	Password string
-	Name     string
-	Port     string
-	Host     string
-	SSLMode  string
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Name     string `yaml:"name"`
+	Port     string `yaml:"port"`
+	Host     string `yaml:"host"`
+	SSLMode  string `yaml:"ssl_mode"`
+
+	MigrateOnStart bool `yaml:"migrate_on_start"`
+
+	// MaxOpenConns and MaxIdleConns limit the connection pool; zero
+	// MaxOpenConns is unlimited.
+	MaxOpenConns int `yaml:"max_open_conns"`
+	MaxIdleConns int `yaml:"max_idle_conns"`
+	// ConnMaxLifetime and ConnMaxIdleTime close older connections; zero
+	// keeps them forever.
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+
+	// ConnectWait is how long startup keeps trying to reach the database;
+	// zero gives up after the first attempt.
+	ConnectWait time.Duration `yaml:"connect_wait"`
+	// RetryAttempts and RetryBackoff apply to operations that fail with a
+	// transient error, such as a lost connection or a serialization failure.
+	RetryAttempts int           `yaml:"retry_attempts"`
+	RetryBackoff  time.Duration `yaml:"retry_backoff"`
 }
 
 type ServerConfig struct {
-	Port string
+	Port string `yaml:"port"`
+	// GRPCPort is where the gRPC API listens; empty disables it.
+	GRPCPort string `yaml:"grpc_port"`
+
+	// ReadTimeout, WriteTimeout and IdleTimeout apply to the HTTP server.
+	// WriteTimeout also ends /events streams, so it is off by default.
+	ReadTimeout  time.Duration `yaml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout"`
+	// ShutdownTimeout is how long in-flight requests get on shutdown.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// BodyLimit is the largest accepted request body, like "4M".
+	BodyLimit string `yaml:"body_limit"`
+
+	IdempotencyTTL time.Duration `yaml:"idempotency_ttl"`
+	// ValidateRequests rejects requests that do not match api/openapi.yml.
+	ValidateRequests bool `yaml:"validate_requests"`
+	// ValidateResponses turns responses that do not match the spec into
+	// 500. It buffers every response and is meant for tests.
+	ValidateResponses bool `yaml:"validate_responses"`
+	// TrustProxy takes the client IP from X-Forwarded-For and X-Real-IP,
+	// for logs and rate limits. Only enable it behind a proxy that sets them.
+	TrustProxy bool `yaml:"trust_proxy"`
+}
+
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Store is memory, counting per replica, or postgres, shared by all.
+	Store string `yaml:"store"`
+	// Default applies to every route without a limit in Routes, which are
+	// keyed by operationId from api/openapi.yml.
+	Default RateLimit            `yaml:"default"`
+	Routes  map[string]RateLimit `yaml:"routes"`
+}
+
+// RateLimit allows Requests per Per, and up to Burst at once. Zero Requests
+// is unlimited; zero Burst equals Requests.
+type RateLimit struct {
+	Requests int           `yaml:"requests"`
+	Per      time.Duration `yaml:"per"`
+	Burst    int           `yaml:"burst"`
+}
+
+type AssignmentConfig struct {
+	// Reviewers is how many reviewers a new pull request gets.
+	Reviewers int `yaml:"reviewers"`
+	// Seed makes reviewer selection reproducible; empty picks at random.
+	Seed string `yaml:"seed"`
+	// ReplacementPool is where a replaced reviewer's successor comes from:
+	// reviewer_team, author_team or union.
+	ReplacementPool string `yaml:"replacement_pool"`
+	// DeclineQuota is how many reviews a user may decline per month, for
+	// teams that did not set their own quota.
+	DeclineQuota int `yaml:"decline_quota"`
+}
+
+type AuthConfig struct {
+	GitHubWebhookSecret string `yaml:"github_webhook_secret"`
+	GitLabWebhookToken  string `yaml:"gitlab_webhook_token"`
+}
+
+type SchedulerConfig struct {
+	Enabled          bool          `yaml:"enabled"`
+	Interval         time.Duration `yaml:"interval"`
+	StalePRThreshold time.Duration `yaml:"stale_pr_threshold"`
+	ReassignAfter    time.Duration `yaml:"reassign_after"`
+}
+
+type EventsConfig struct {
+	WebhookURL string `yaml:"webhook_url"`
+	// Retention is how long the event log keeps events for Last-Event-ID
+	// resume. Zero keeps them forever.
+	Retention time.Duration `yaml:"retention"`
+}
+
+type LogConfig struct {
+	Level slog.Level `yaml:"level"`
+	// Format is json or text.
+	Format string `yaml:"format"`
+}
+
+type TracingConfig struct {
+	// Exporter is none, otlp or stdout.
+	Exporter    string  `yaml:"exporter"`
+	ServiceName string  `yaml:"service_name"`
+	SampleRatio float64 `yaml:"sample_ratio"`
 }
 
 type Config struct {
-	DB     DBConfig
-	Server ServerConfig
+	Log     LogConfig     `yaml:"log"`
+	Tracing TracingConfig `yaml:"tracing"`
+	DB      DBConfig      `yaml:"db"`
+	Server  ServerConfig  `yaml:"server"`
+
+	Assignment AssignmentConfig `yaml:"assignment"`
+	Auth       AuthConfig       `yaml:"auth"`
+	RateLimit  RateLimitConfig  `yaml:"rate_limit"`
+	Scheduler  SchedulerConfig  `yaml:"scheduler"`
+	Events     EventsConfig     `yaml:"events"`
+
+	// envProblems are environment variables that could not be parsed. They
+	// are reported by Validate together with the invalid values.
+	envProblems []string
 }
 
-func getEnv(key, def string) string {
-	if v, ok := os.LookupEnv(key); ok {
-		return v
+func defaults() Config {
+	return Config{
+		Log: LogConfig{
+			Level:  slog.LevelInfo,
+			Format: "json",
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			ServiceName: "avito-pullrequest",
+			SampleRatio: 1,
+		},
+		DB: DBConfig{
+			Port:    "5432",
+			SSLMode: "disable",
+
+			MaxOpenConns:    20,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+
+			ConnectWait:   30 * time.Second,
+			RetryAttempts: 3,
+			RetryBackoff:  50 * time.Millisecond,
+		},
+		Server: ServerConfig{
+			Port:     "8080",
+			GRPCPort: "9090",
+
+			ReadTimeout:     30 * time.Second,
+			IdleTimeout:     2 * time.Minute,
+			ShutdownTimeout: 10 * time.Second,
+			BodyLimit:       "4M",
+
+			IdempotencyTTL:   24 * time.Hour,
+			ValidateRequests: true,
+		},
+		Assignment: AssignmentConfig{
+			Reviewers:       2,
+			ReplacementPool: "reviewer_team",
+			DeclineQuota:    3,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: true,
+			Store:   "memory",
+			Default: RateLimit{Requests: 20, Per: time.Second, Burst: 40},
+			Routes: map[string]RateLimit{
+				"pullRequestCreate": {Requests: 30, Per: time.Minute, Burst: 10},
+			},
+		},
+		Scheduler: SchedulerConfig{
+			Enabled:          true,
+			Interval:         5 * time.Minute,
+			StalePRThreshold: 72 * time.Hour,
+		},
+		Events: EventsConfig{
+			Retention: 7 * 24 * time.Hour,
+		},
 	}
-	return def
 }
 
-func LoadConfig() (*Config, error) {
+// Load builds the configuration from the defaults, the YAML file named by
+// CONFIG_FILE and the environment, each overriding the previous one. The
+// result is not validated; see LoadConfig.
+func Load() (*Config, error) {
 	err := godotenv.Load()
 	if err != nil {
-		fmt.Println("Warning: .env file not found, using environment variables")
+		fmt.Fprintln(os.Stderr, "Warning: .env file not found, using environment variables")
 	}
 
-	cfg := &Config{
-		DB: DBConfig{
-			Host:     os.Getenv("DB_HOST"),
-			User:     os.Getenv("DB_USER"),
-			Password: os.Getenv("DB_PASSWORD"),
-			Name:     os.Getenv("DB_NAME"),
-			Port:     getEnv("DB_PORT", "5432"),
-			SSLMode:  getEnv("DB_SSL_MODE", "disable"),
-		},
-		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
-		},
+	cfg := defaults()
+
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return nil, err
+		}
 	}
 
-	if cfg.DB.Host == "" || cfg.DB.User == "" || cfg.DB.Password == "" || cfg.DB.Name == "" {
-		return nil, fmt.Errorf("db config is incomplete")
+	applyEnv(&cfg)
+
+	return &cfg, nil
+}
+
+// LoadConfig is Load followed by Validate.
+func LoadConfig() (*Config, error) {
+	cfg, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
 
 	return cfg, nil
 }
+
+func loadFile(path string, cfg *Config) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open config file: %w", err)
+	}
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+const redacted = "<redacted>"
+
+// Redacted returns a copy of c with passwords, secrets and tokens replaced,
+// for printing. Empty ones stay empty, so it is visible whether they are set.
+func (c Config) Redacted() Config {
+	for _, s := range []*string{
+		&c.DB.Password,
+		&c.Auth.GitHubWebhookSecret,
+		&c.Auth.GitLabWebhookToken,
+	} {
+		if *s != "" {
+			*s = redacted
+		}
+	}
+	c.Events.WebhookURL = redactURL(c.Events.WebhookURL)
+	return c
+}
+
+// redactURL replaces the userinfo and the query of rawURL, where credentials
+// of webhook receivers usually go, and drops the fragment. A URL that does not
+// parse is replaced whole.
+func redactURL(rawURL string) string {
+	if rawURL == "" {
+		return ""
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return redacted
+	}
+
+	hasUser, hasQuery := u.User != nil, u.RawQuery != "" || u.ForceQuery
+	u.User, u.RawQuery, u.ForceQuery, u.Fragment, u.RawFragment = nil, "", false, "", ""
+
+	out := u.String()
+	if hasUser {
+		out = strings.Replace(out, "//", "//"+redacted+"@", 1)
+	}
+	if hasQuery {
+		out += "?" + redacted
+	}
+	return out
+}
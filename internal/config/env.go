@@ -0,0 +1,156 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+)
+
+// applyEnv overrides cfg with the environment variables that are set. An
+// empty variable counts as unset, except GRPC_PORT where it disables gRPC.
+// Values that cannot be parsed leave the setting unchanged and are recorded
+// in cfg.envProblems.
+func applyEnv(cfg *Config) {
+	e := &envReader{}
+
+	e.level(&cfg.Log.Level, "LOG_LEVEL")
+	e.string(&cfg.Log.Format, "LOG_FORMAT")
+
+	e.string(&cfg.Tracing.Exporter, "TRACING_EXPORTER")
+	e.string(&cfg.Tracing.ServiceName, "TRACING_SERVICE_NAME")
+	e.float(&cfg.Tracing.SampleRatio, "TRACING_SAMPLE_RATIO")
+
+	e.string(&cfg.DB.Host, "DB_HOST")
+	e.string(&cfg.DB.Port, "DB_PORT")
+	e.string(&cfg.DB.User, "DB_USER")
+	e.string(&cfg.DB.Password, "DB_PASSWORD")
+	e.string(&cfg.DB.Name, "DB_NAME")
+	e.string(&cfg.DB.SSLMode, "DB_SSL_MODE")
+	e.bool(&cfg.DB.MigrateOnStart, "MIGRATE_ON_START")
+	e.int(&cfg.DB.MaxOpenConns, "DB_MAX_OPEN_CONNS")
+	e.int(&cfg.DB.MaxIdleConns, "DB_MAX_IDLE_CONNS")
+	e.duration(&cfg.DB.ConnMaxLifetime, "DB_CONN_MAX_LIFETIME")
+	e.duration(&cfg.DB.ConnMaxIdleTime, "DB_CONN_MAX_IDLE_TIME")
+	e.duration(&cfg.DB.ConnectWait, "DB_CONNECT_WAIT")
+	e.int(&cfg.DB.RetryAttempts, "DB_RETRY_ATTEMPTS")
+	e.duration(&cfg.DB.RetryBackoff, "DB_RETRY_BACKOFF")
+
+	e.string(&cfg.Server.Port, "SERVER_PORT")
+	if v, ok := os.LookupEnv("GRPC_PORT"); ok {
+		cfg.Server.GRPCPort = v
+	}
+	e.duration(&cfg.Server.ReadTimeout, "SERVER_READ_TIMEOUT")
+	e.duration(&cfg.Server.WriteTimeout, "SERVER_WRITE_TIMEOUT")
+	e.duration(&cfg.Server.IdleTimeout, "SERVER_IDLE_TIMEOUT")
+	e.duration(&cfg.Server.ShutdownTimeout, "SERVER_SHUTDOWN_TIMEOUT")
+	e.string(&cfg.Server.BodyLimit, "SERVER_BODY_LIMIT")
+	e.duration(&cfg.Server.IdempotencyTTL, "IDEMPOTENCY_TTL")
+	e.bool(&cfg.Server.ValidateRequests, "OPENAPI_VALIDATE_REQUESTS")
+	e.bool(&cfg.Server.ValidateResponses, "OPENAPI_VALIDATE_RESPONSES")
+	e.bool(&cfg.Server.TrustProxy, "SERVER_TRUST_PROXY")
+
+	e.int(&cfg.Assignment.Reviewers, "ASSIGNMENT_REVIEWERS")
+	e.string(&cfg.Assignment.Seed, "ASSIGNMENT_SEED")
+	e.string(&cfg.Assignment.ReplacementPool, "ASSIGNMENT_REPLACEMENT_POOL")
+	e.int(&cfg.Assignment.DeclineQuota, "ASSIGNMENT_DECLINE_QUOTA")
+
+	e.string(&cfg.Auth.GitHubWebhookSecret, "GITHUB_WEBHOOK_SECRET")
+	e.string(&cfg.Auth.GitLabWebhookToken, "GITLAB_WEBHOOK_TOKEN")
+
+	e.bool(&cfg.RateLimit.Enabled, "RATE_LIMIT_ENABLED")
+	e.string(&cfg.RateLimit.Store, "RATE_LIMIT_STORE")
+	e.int(&cfg.RateLimit.Default.Requests, "RATE_LIMIT_REQUESTS")
+	e.duration(&cfg.RateLimit.Default.Per, "RATE_LIMIT_PER")
+	e.int(&cfg.RateLimit.Default.Burst, "RATE_LIMIT_BURST")
+
+	e.bool(&cfg.Scheduler.Enabled, "SCHEDULER_ENABLED")
+	e.duration(&cfg.Scheduler.Interval, "SCHEDULER_INTERVAL")
+	e.duration(&cfg.Scheduler.StalePRThreshold, "STALE_PR_THRESHOLD")
+	e.duration(&cfg.Scheduler.ReassignAfter, "STALE_REVIEW_REASSIGN_AFTER")
+
+	e.string(&cfg.Events.WebhookURL, "EVENTS_WEBHOOK_URL")
+	e.duration(&cfg.Events.Retention, "EVENTS_RETENTION")
+
+	cfg.envProblems = e.problems
+}
+
+type envReader struct {
+	problems []string
+}
+
+func (e *envReader) string(dst *string, key string) {
+	if v := os.Getenv(key); v != "" {
+		*dst = v
+	}
+}
+
+func (e *envReader) bool(dst *bool, key string) {
+	v := os.Getenv(key)
+	if v == "" {
+		return
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		e.invalid(key, v, "a boolean")
+		return
+	}
+	*dst = b
+}
+
+func (e *envReader) int(dst *int, key string) {
+	v := os.Getenv(key)
+	if v == "" {
+		return
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		e.invalid(key, v, "an integer")
+		return
+	}
+	*dst = n
+}
+
+func (e *envReader) float(dst *float64, key string) {
+	v := os.Getenv(key)
+	if v == "" {
+		return
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		e.invalid(key, v, "a number")
+		return
+	}
+	*dst = f
+}
+
+func (e *envReader) duration(dst *time.Duration, key string) {
+	v := os.Getenv(key)
+	if v == "" {
+		return
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		e.invalid(key, v, `a duration like "30s" or "5m"`)
+		return
+	}
+	*dst = d
+}
+
+func (e *envReader) level(dst *slog.Level, key string) {
+	v := os.Getenv(key)
+	if v == "" {
+		return
+	}
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(v)); err != nil {
+		e.invalid(key, v, "one of debug, info, warn, error")
+		return
+	}
+	*dst = l
+}
+
+func (e *envReader) invalid(key, value, want string) {
+	e.problems = append(e.problems, fmt.Sprintf("%s: %q is not %s", key, value, want))
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/gommon/bytes"
+)
+
+// ValidationError lists every invalid setting, so that they can be fixed in
+// one go.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid config:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+// Validate checks every setting and reports all problems at once. Each one
+// names the file key and the environment variable of the setting.
+func (c *Config) Validate() error {
+	v := &validator{problems: append([]string(nil), c.envProblems...)}
+
+	v.oneOf(c.Log.Format, "log.format", "LOG_FORMAT", "json", "text")
+
+	v.oneOf(c.Tracing.Exporter, "tracing.exporter", "TRACING_EXPORTER", "none", "otlp", "stdout")
+	v.check(c.Tracing.ServiceName != "", "tracing.service_name", "TRACING_SERVICE_NAME", "is required")
+	v.check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1,
+		"tracing.sample_ratio", "TRACING_SAMPLE_RATIO", "must be between 0 and 1")
+
+	v.check(c.DB.Host != "", "db.host", "DB_HOST", "is required")
+	v.port(c.DB.Port, "db.port", "DB_PORT")
+	v.check(c.DB.User != "", "db.user", "DB_USER", "is required")
+	v.check(c.DB.Password != "", "db.password", "DB_PASSWORD", "is required")
+	v.check(c.DB.Name != "", "db.name", "DB_NAME", "is required")
+	v.oneOf(c.DB.SSLMode, "db.ssl_mode", "DB_SSL_MODE",
+		"disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+	v.check(c.DB.MaxOpenConns >= 0, "db.max_open_conns", "DB_MAX_OPEN_CONNS", "must not be negative")
+	v.check(c.DB.MaxIdleConns >= 0, "db.max_idle_conns", "DB_MAX_IDLE_CONNS", "must not be negative")
+	v.check(c.DB.MaxOpenConns == 0 || c.DB.MaxIdleConns <= c.DB.MaxOpenConns,
+		"db.max_idle_conns", "DB_MAX_IDLE_CONNS", "must not exceed db.max_open_conns")
+	v.check(c.DB.ConnMaxLifetime >= 0, "db.conn_max_lifetime", "DB_CONN_MAX_LIFETIME", "must not be negative")
+	v.check(c.DB.ConnMaxIdleTime >= 0, "db.conn_max_idle_time", "DB_CONN_MAX_IDLE_TIME", "must not be negative")
+	v.check(c.DB.ConnectWait >= 0, "db.connect_wait", "DB_CONNECT_WAIT", "must not be negative")
+	v.check(c.DB.RetryAttempts > 0, "db.retry_attempts", "DB_RETRY_ATTEMPTS", "must be positive")
+	v.check(c.DB.RetryBackoff > 0, "db.retry_backoff", "DB_RETRY_BACKOFF", "must be positive")
+
+	v.port(c.Server.Port, "server.port", "SERVER_PORT")
+	if c.Server.GRPCPort != "" {
+		v.port(c.Server.GRPCPort, "server.grpc_port", "GRPC_PORT")
+		v.check(c.Server.GRPCPort != c.Server.Port, "server.grpc_port", "GRPC_PORT", "must differ from server.port")
+	}
+	v.check(c.Server.ReadTimeout >= 0, "server.read_timeout", "SERVER_READ_TIMEOUT", "must not be negative")
+	v.check(c.Server.WriteTimeout >= 0, "server.write_timeout", "SERVER_WRITE_TIMEOUT", "must not be negative")
+	v.check(c.Server.IdleTimeout >= 0, "server.idle_timeout", "SERVER_IDLE_TIMEOUT", "must not be negative")
+	v.check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout", "SERVER_SHUTDOWN_TIMEOUT", "must be positive")
+	if n, err := bytes.Parse(c.Server.BodyLimit); err != nil || n <= 0 {
+		v.problem("server.body_limit", "SERVER_BODY_LIMIT", `must be a size like "512K" or "4M", got %q`, c.Server.BodyLimit)
+	}
+	v.check(c.Server.IdempotencyTTL > 0, "server.idempotency_ttl", "IDEMPOTENCY_TTL", "must be positive")
+
+	v.check(c.Assignment.Reviewers > 0, "assignment.reviewers", "ASSIGNMENT_REVIEWERS", "must be positive")
+	v.oneOf(c.Assignment.ReplacementPool, "assignment.replacement_pool", "ASSIGNMENT_REPLACEMENT_POOL", "reviewer_team", "author_team", "union")
+	v.check(c.Assignment.DeclineQuota >= 0, "assignment.decline_quota", "ASSIGNMENT_DECLINE_QUOTA", "must not be negative")
+
+	v.oneOf(c.RateLimit.Store, "rate_limit.store", "RATE_LIMIT_STORE", "memory", "postgres")
+	v.rateLimit(c.RateLimit.Default, "rate_limit.default", "RATE_LIMIT_")
+	routes := make([]string, 0, len(c.RateLimit.Routes))
+	for name := range c.RateLimit.Routes {
+		routes = append(routes, name)
+	}
+	slices.Sort(routes)
+	for _, name := range routes {
+		v.rateLimit(c.RateLimit.Routes[name], "rate_limit.routes."+name, "")
+	}
+
+	v.check(c.Scheduler.Interval > 0, "scheduler.interval", "SCHEDULER_INTERVAL", "must be positive")
+	v.check(c.Scheduler.StalePRThreshold > 0, "scheduler.stale_pr_threshold", "STALE_PR_THRESHOLD", "must be positive")
+	v.check(c.Scheduler.ReassignAfter >= 0, "scheduler.reassign_after", "STALE_REVIEW_REASSIGN_AFTER", "must not be negative")
+
+	if c.Events.WebhookURL != "" {
+		u, err := url.Parse(c.Events.WebhookURL)
+		v.check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+			"events.webhook_url", "EVENTS_WEBHOOK_URL", "must be an http or https URL")
+	}
+	v.check(c.Events.Retention >= 0, "events.retention", "EVENTS_RETENTION", "must not be negative")
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+type validator struct {
+	problems []string
+}
+
+func (v *validator) check(ok bool, key, env, msg string) {
+	if !ok {
+		v.problem(key, env, "%s", msg)
+	}
+}
+
+func (v *validator) problem(key, env, format string, args ...any) {
+	v.problems = append(v.problems, fmt.Sprintf("%s (%s): %s", key, env, fmt.Sprintf(format, args...)))
+}
+
+func (v *validator) oneOf(value, key, env string, allowed ...string) {
+	if !slices.Contains(allowed, value) {
+		v.problem(key, env, "must be one of %s, got %q", strings.Join(allowed, ", "), value)
+	}
+}
+
+// rateLimit checks a limit. Route limits can only be set in the file, so
+// they have no environment variable and envPrefix is empty.
+func (v *validator) rateLimit(l RateLimit, key, envPrefix string) {
+	env := func(name string) string {
+		if envPrefix == "" {
+			return "file only"
+		}
+		return envPrefix + name
+	}
+
+	v.check(l.Requests >= 0, key+".requests", env("REQUESTS"), "must not be negative")
+	v.check(l.Burst >= 0, key+".burst", env("BURST"), "must not be negative")
+	if l.Requests > 0 {
+		v.check(l.Per > 0, key+".per", env("PER"), "must be positive")
+	}
+}
+
+func (v *validator) port(value, key, env string) {
+	if p, err := strconv.Atoi(value); err != nil || p < 1 || p > 65535 {
+		v.problem(key, env, "must be a port number, got %q", value)
+	}
+}
@@ -0,0 +1,98 @@
+// Package graphql serves the read-only GraphQL API for dashboards.
+package graphql
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+	"github.com/labstack/echo/v4"
+
+	"github.com/Wucop228/avito-PullRequest/internal/service"
+)
+
+type Handler struct {
+	svc    *service.QueryService
+	schema graphql.Schema
+}
+
+func NewHandler(svc *service.QueryService) (*Handler, error) {
+	schema, err := newSchema(svc)
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{svc: svc, schema: schema}, nil
+}
+
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Query executes a GraphQL query sent as a JSON body (POST) or in the
+// query, operationName and variables query parameters (GET). Errors in the
+// query itself are reported in the errors field with status 200, as the
+// GraphQL over HTTP convention goes.
+func (h *Handler) Query(c echo.Context) error {
+	var req request
+	if c.Request().Method == http.MethodGet {
+		req.Query = c.QueryParam("query")
+		req.OperationName = c.QueryParam("operationName")
+		if v := c.QueryParam("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				return c.JSON(http.StatusBadRequest, echo.Map{
+					"error": echo.Map{
+						"code":    "BAD_REQUEST",
+						"message": "variables must be a JSON object",
+					},
+				})
+			}
+		}
+	} else if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": echo.Map{
+				"code":    "BAD_REQUEST",
+				"message": err.Error(),
+			},
+		})
+	}
+
+	if req.Query == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": echo.Map{
+				"code":    "BAD_REQUEST",
+				"message": "query is required",
+			},
+		})
+	}
+
+	ctx := c.Request().Context()
+	ctx = withLoaders(ctx, newLoaders(ctx, h.svc))
+	result := graphql.Do(graphql.Params{
+		Schema:         h.schema,
+		RequestString:  req.Query,
+		OperationName:  req.OperationName,
+		VariableValues: req.Variables,
+		Context:        ctx,
+	})
+
+	// Errors in the query itself have no original error; the rest come from
+	// the services. A failed batch fails every field it fed, so each distinct
+	// error is logged once.
+	logged := make(map[string]struct{})
+	for _, e := range result.Errors {
+		orig := e.OriginalError()
+		if orig == nil {
+			continue
+		}
+		if _, ok := logged[orig.Error()]; ok {
+			continue
+		}
+		logged[orig.Error()] = struct{}{}
+		slog.ErrorContext(ctx, "graphql resolver failed", "path", e.Path, "error", orig)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
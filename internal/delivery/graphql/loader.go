@@ -0,0 +1,119 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+	"github.com/Wucop228/avito-PullRequest/internal/service"
+)
+
+// loader batches lookups by key. load only queues the key and returns a
+// thunk; the first thunk to run fetches every queued key at once. The
+// executor runs the thunks of one level of the query after all of that
+// level's fields were resolved, so each level costs one query per loader.
+type loader[V any] struct {
+	// ctx is the context of the request the loader belongs to.
+	ctx   context.Context
+	fetch func(ctx context.Context, keys []string) (map[string]V, error)
+
+	mu      sync.Mutex
+	queue   []string
+	queued  map[string]struct{}
+	fetched map[string]struct{}
+	results map[string]V
+	errs    map[string]error
+}
+
+func newLoader[V any](ctx context.Context, fetch func(ctx context.Context, keys []string) (map[string]V, error)) *loader[V] {
+	return &loader[V]{
+		ctx:     ctx,
+		fetch:   fetch,
+		queued:  make(map[string]struct{}),
+		fetched: make(map[string]struct{}),
+		results: make(map[string]V),
+		errs:    make(map[string]error),
+	}
+}
+
+// load returns a thunk yielding the value for key and whether it exists.
+// Results are cached for the loader's lifetime, i.e. one request.
+func (l *loader[V]) load(key string) func() (V, bool, error) {
+	l.mu.Lock()
+	_, fetched := l.fetched[key]
+	_, queued := l.queued[key]
+	if !fetched && !queued {
+		l.queued[key] = struct{}{}
+		l.queue = append(l.queue, key)
+	}
+	l.mu.Unlock()
+
+	return func() (V, bool, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if _, ok := l.queued[key]; ok {
+			l.flush()
+		}
+		if err := l.errs[key]; err != nil {
+			var zero V
+			return zero, false, err
+		}
+		v, ok := l.results[key]
+		return v, ok, nil
+	}
+}
+
+// flush must be called with mu held.
+func (l *loader[V]) flush() {
+	keys := l.queue
+	l.queue = nil
+	for _, k := range keys {
+		delete(l.queued, k)
+		l.fetched[k] = struct{}{}
+	}
+
+	found, err := l.fetch(l.ctx, keys)
+	for _, k := range keys {
+		if err != nil {
+			l.errs[k] = err
+			continue
+		}
+		if v, ok := found[k]; ok {
+			l.results[k] = v
+		}
+	}
+}
+
+// loaders holds the per-request loaders.
+type loaders struct {
+	users                *loader[models.User]
+	pullRequests         *loader[models.PullRequest]
+	members              *loader[[]models.User]
+	reviewers            *loader[[]models.User]
+	pullRequestsByAuthor *loader[[]models.PullRequest]
+	reviews              *loader[[]models.PullRequest]
+	assignments          *loader[[]models.ReviewAssignmentRecord]
+}
+
+func newLoaders(ctx context.Context, svc *service.QueryService) *loaders {
+	return &loaders{
+		users:                newLoader(ctx, svc.UsersByID),
+		pullRequests:         newLoader(ctx, svc.PullRequestsByID),
+		members:              newLoader(ctx, svc.MembersByTeam),
+		reviewers:            newLoader(ctx, svc.ReviewersByPullRequest),
+		pullRequestsByAuthor: newLoader(ctx, svc.PullRequestsByAuthor),
+		reviews:              newLoader(ctx, svc.PullRequestsByReviewer),
+		assignments:          newLoader(ctx, svc.AssignmentsByPullRequest),
+	}
+}
+
+type loadersKey struct{}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
@@ -0,0 +1,372 @@
+package graphql
+
+import (
+	"github.com/graphql-go/graphql"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+	"github.com/Wucop228/avito-PullRequest/internal/service"
+)
+
+// team is the source of the Team type. Members are loaded lazily.
+type team struct {
+	Name string
+}
+
+var pullRequestStatusEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name: "PullRequestStatus",
+	Values: graphql.EnumValueConfigMap{
+		"OPEN":   {Value: "OPEN"},
+		"MERGED": {Value: "MERGED"},
+		"CLOSED": {Value: "CLOSED"},
+	},
+})
+
+var assignmentEndReasonEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name: "AssignmentEndReason",
+	Values: graphql.EnumValueConfigMap{
+		"MERGED":     {Value: "merged"},
+		"REASSIGNED": {Value: "reassigned"},
+		"CLOSED":     {Value: "closed"},
+		"REMOVED":    {Value: "removed"},
+		"DECLINED":   {Value: "declined"},
+	},
+})
+
+var statusArg = graphql.FieldConfigArgument{
+	"status": {
+		Type:        pullRequestStatusEnum,
+		Description: "Only pull requests with this status.",
+	},
+}
+
+// newSchema builds the read-only schema. Every nested list and reference
+// goes through the request's loaders, so a level of the query costs one
+// query per kind of data regardless of the number of parents.
+func newSchema(svc *service.QueryService) (graphql.Schema, error) {
+	var teamType, userType, pullRequestType, assignmentType *graphql.Object
+
+	teamType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Team",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"name": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.String),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(team).Name, nil
+					},
+				},
+				"members": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(userType))),
+					Args: graphql.FieldConfigArgument{
+						"activeOnly": {Type: graphql.Boolean, DefaultValue: false},
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						thunk := loadersFrom(p.Context).members.load(p.Source.(team).Name)
+						activeOnly, _ := p.Args["activeOnly"].(bool)
+						return func() (interface{}, error) {
+							members, _, err := thunk()
+							if err != nil {
+								return nil, err
+							}
+							res := make([]models.User, 0, len(members))
+							for _, u := range members {
+								if !activeOnly || u.IsActive {
+									res = append(res, u)
+								}
+							}
+							return res, nil
+						}, nil
+					},
+				},
+			}
+		}),
+	})
+
+	userType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.String),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(models.User).UserID, nil
+					},
+				},
+				"username": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.String),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(models.User).Username, nil
+					},
+				},
+				"isActive": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.Boolean),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(models.User).IsActive, nil
+					},
+				},
+				"team": &graphql.Field{
+					Type: graphql.NewNonNull(teamType),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return team{Name: p.Source.(models.User).TeamName}, nil
+					},
+				},
+				"reviews": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(pullRequestType))),
+					Description: "Pull requests the user is currently assigned to review.",
+					Args:        statusArg,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						thunk := loadersFrom(p.Context).reviews.load(p.Source.(models.User).UserID)
+						return filterByStatus(thunk, p.Args), nil
+					},
+				},
+				"pullRequests": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(pullRequestType))),
+					Description: "Pull requests authored by the user.",
+					Args:        statusArg,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						thunk := loadersFrom(p.Context).pullRequestsByAuthor.load(p.Source.(models.User).UserID)
+						return filterByStatus(thunk, p.Args), nil
+					},
+				},
+			}
+		}),
+	})
+
+	pullRequestType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "PullRequest",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.String),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(models.PullRequest).PullRequestID, nil
+					},
+				},
+				"name": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.String),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(models.PullRequest).PullRequestName, nil
+					},
+				},
+				"status": &graphql.Field{
+					Type: graphql.NewNonNull(pullRequestStatusEnum),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(models.PullRequest).Status, nil
+					},
+				},
+				"createdAt": &graphql.Field{
+					Type: graphql.DateTime,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(models.PullRequest).CreatedAt, nil
+					},
+				},
+				"mergedAt": &graphql.Field{
+					Type: graphql.DateTime,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(models.PullRequest).MergedAt, nil
+					},
+				},
+				"author": &graphql.Field{
+					Type: userType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return loadUser(p, p.Source.(models.PullRequest).AuthorID), nil
+					},
+				},
+				"reviewers": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(userType))),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						thunk := loadersFrom(p.Context).reviewers.load(p.Source.(models.PullRequest).PullRequestID)
+						return func() (interface{}, error) {
+							users, _, err := thunk()
+							if err != nil {
+								return nil, err
+							}
+							if users == nil {
+								users = []models.User{}
+							}
+							return users, nil
+						}, nil
+					},
+				},
+				"assignments": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(assignmentType))),
+					Description: "Reviewer assignment history, oldest first.",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						thunk := loadersFrom(p.Context).assignments.load(p.Source.(models.PullRequest).PullRequestID)
+						return func() (interface{}, error) {
+							records, _, err := thunk()
+							if err != nil {
+								return nil, err
+							}
+							if records == nil {
+								records = []models.ReviewAssignmentRecord{}
+							}
+							return records, nil
+						}, nil
+					},
+				},
+			}
+		}),
+	})
+
+	assignmentType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "ReviewAssignment",
+		Fields: graphql.Fields{
+			"pullRequest": &graphql.Field{
+				Type: pullRequestType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadPullRequest(p, p.Source.(models.ReviewAssignmentRecord).PullRequestID), nil
+				},
+			},
+			"reviewer": &graphql.Field{
+				Type: userType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadUser(p, p.Source.(models.ReviewAssignmentRecord).ReviewerID), nil
+				},
+			},
+			"assignedAt": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.DateTime),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(models.ReviewAssignmentRecord).AssignedAt, nil
+				},
+			},
+			"endedAt": &graphql.Field{
+				Type: graphql.DateTime,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(models.ReviewAssignmentRecord).EndedAt, nil
+				},
+			},
+			"endReason": &graphql.Field{
+				Type: assignmentEndReasonEnum,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if r := p.Source.(models.ReviewAssignmentRecord).EndReason; r != "" {
+						return r, nil
+					}
+					return nil, nil
+				},
+			},
+			"declineReason": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if r := p.Source.(models.ReviewAssignmentRecord).DeclineReason; r != "" {
+						return r, nil
+					}
+					return nil, nil
+				},
+			},
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"teams": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(teamType))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					names, err := svc.ListTeamNames(p.Context)
+					if err != nil {
+						return nil, err
+					}
+					teams := make([]team, 0, len(names))
+					for _, name := range names {
+						teams = append(teams, team{Name: name})
+					}
+					return teams, nil
+				},
+			},
+			"team": &graphql.Field{
+				Type: teamType,
+				Args: graphql.FieldConfigArgument{
+					"name": {Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					name := p.Args["name"].(string)
+					exists, err := svc.TeamsExist(p.Context, []string{name})
+					if err != nil {
+						return nil, err
+					}
+					if !exists[name] {
+						return nil, nil
+					}
+					return team{Name: name}, nil
+				},
+			},
+			"user": &graphql.Field{
+				Type: userType,
+				Args: graphql.FieldConfigArgument{
+					"id": {Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadUser(p, p.Args["id"].(string)), nil
+				},
+			},
+			"pullRequest": &graphql.Field{
+				Type: pullRequestType,
+				Args: graphql.FieldConfigArgument{
+					"id": {Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadPullRequest(p, p.Args["id"].(string)), nil
+				},
+			},
+			"pullRequests": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(pullRequestType))),
+				Description: "Newest pull requests first.",
+				Args: graphql.FieldConfigArgument{
+					"status":   {Type: pullRequestStatusEnum},
+					"authorId": {Type: graphql.String},
+					"first":    {Type: graphql.Int, DefaultValue: 50},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					status, _ := p.Args["status"].(string)
+					authorID, _ := p.Args["authorId"].(string)
+					first, _ := p.Args["first"].(int)
+					return svc.ListPullRequests(p.Context, status, authorID, first)
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+}
+
+func loadUser(p graphql.ResolveParams, id string) func() (interface{}, error) {
+	thunk := loadersFrom(p.Context).users.load(id)
+	return func() (interface{}, error) {
+		u, ok, err := thunk()
+		if err != nil || !ok {
+			return nil, err
+		}
+		return u, nil
+	}
+}
+
+func loadPullRequest(p graphql.ResolveParams, id string) func() (interface{}, error) {
+	thunk := loadersFrom(p.Context).pullRequests.load(id)
+	return func() (interface{}, error) {
+		pr, ok, err := thunk()
+		if err != nil || !ok {
+			return nil, err
+		}
+		return pr, nil
+	}
+}
+
+// filterByStatus completes a list of pull requests, keeping those with the
+// status argument when it is given.
+func filterByStatus(thunk func() ([]models.PullRequest, bool, error), args map[string]interface{}) func() (interface{}, error) {
+	status, _ := args["status"].(string)
+	return func() (interface{}, error) {
+		prs, _, err := thunk()
+		if err != nil {
+			return nil, err
+		}
+		res := make([]models.PullRequest, 0, len(prs))
+		for _, pr := range prs {
+			if status == "" || pr.Status == status {
+				res = append(res, pr)
+			}
+		}
+		return res, nil
+	}
+}
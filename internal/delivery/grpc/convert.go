@@ -0,0 +1,73 @@
+package grpc
+
+import (
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	prv1 "github.com/Wucop228/avito-PullRequest/api/gen/pr/v1"
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+)
+
+var pullRequestStatuses = map[string]prv1.PullRequestStatus{
+	"OPEN":   prv1.PullRequestStatus_PULL_REQUEST_STATUS_OPEN,
+	"MERGED": prv1.PullRequestStatus_PULL_REQUEST_STATUS_MERGED,
+	"CLOSED": prv1.PullRequestStatus_PULL_REQUEST_STATUS_CLOSED,
+}
+
+func teamToProto(team *models.RequestTeamAdd) *prv1.Team {
+	members := make([]*prv1.TeamMember, 0, len(team.Members))
+	for _, m := range team.Members {
+		members = append(members, &prv1.TeamMember{
+			UserId:   m.UserID,
+			Username: m.Username,
+			IsActive: m.IsActive,
+		})
+	}
+	return &prv1.Team{TeamName: team.TeamName, Members: members}
+}
+
+func teamFromProto(team *prv1.Team) *models.RequestTeamAdd {
+	members := make([]models.TeamMember, 0, len(team.GetMembers()))
+	for _, m := range team.GetMembers() {
+		members = append(members, models.TeamMember{
+			UserID:   m.GetUserId(),
+			Username: m.GetUsername(),
+			IsActive: m.GetIsActive(),
+		})
+	}
+	return &models.RequestTeamAdd{TeamName: team.GetTeamName(), Members: members}
+}
+
+func userToProto(user *models.User) *prv1.User {
+	return &prv1.User{
+		UserId:   user.UserID,
+		Username: user.Username,
+		TeamName: user.TeamName,
+		IsActive: user.IsActive,
+	}
+}
+
+func pullRequestToProto(pr *models.PullRequest) *prv1.PullRequest {
+	res := &prv1.PullRequest{
+		PullRequestId:     pr.PullRequestID,
+		PullRequestName:   pr.PullRequestName,
+		AuthorId:          pr.AuthorID,
+		Status:            pullRequestStatuses[pr.Status],
+		AssignedReviewers: pr.AssignedReviewers,
+	}
+	if pr.CreatedAt != nil {
+		res.CreatedAt = timestamppb.New(*pr.CreatedAt)
+	}
+	if pr.MergedAt != nil {
+		res.MergedAt = timestamppb.New(*pr.MergedAt)
+	}
+	return res
+}
+
+func pullRequestShortToProto(pr models.PullRequestShort) *prv1.PullRequestShort {
+	return &prv1.PullRequestShort{
+		PullRequestId:   pr.PullRequestID,
+		PullRequestName: pr.PullRequestName,
+		AuthorId:        pr.AuthorID,
+		Status:          pullRequestStatuses[pr.Status],
+	}
+}
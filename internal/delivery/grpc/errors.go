@@ -0,0 +1,63 @@
+package grpc
+
+import (
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Wucop228/avito-PullRequest/internal/service"
+)
+
+const errorDomain = "avito-pullrequest"
+
+type errorMapping struct {
+	target error
+	code   codes.Code
+	reason string
+}
+
+// errorMappings follows the HTTP handlers: the reason is the HTTP error code
+// and the gRPC code matches the HTTP status.
+var errorMappings = []errorMapping{
+	{service.ErrTeamExists, codes.AlreadyExists, "TEAM_EXISTS"},
+	{service.ErrPRExists, codes.AlreadyExists, "PR_EXISTS"},
+	{service.ErrTeamNotFound, codes.NotFound, "NOT_FOUND"},
+	{service.ErrUserNotFound, codes.NotFound, "NOT_FOUND"},
+	{service.ErrAuthorNotFound, codes.NotFound, "NOT_FOUND"},
+	{service.ErrPRNotFound, codes.NotFound, "NOT_FOUND"},
+	{service.ErrPRMerged, codes.FailedPrecondition, "PR_MERGED"},
+	{service.ErrPRAlreadyMerged, codes.FailedPrecondition, "PR_MERGED"},
+	{service.ErrPRNotOpen, codes.FailedPrecondition, "PR_NOT_OPEN"},
+	{service.ErrReviewerNotAssigned, codes.FailedPrecondition, "NOT_ASSIGNED"},
+	{service.ErrReviewerAssigned, codes.FailedPrecondition, "ALREADY_ASSIGNED"},
+	{service.ErrReviewerIsAuthor, codes.FailedPrecondition, "INVALID_REVIEWER"},
+	{service.ErrReviewerInactive, codes.FailedPrecondition, "INVALID_REVIEWER"},
+	{service.ErrNoCandidate, codes.FailedPrecondition, "NO_CANDIDATE"},
+	{service.ErrDeclineQuotaExceeded, codes.FailedPrecondition, "DECLINE_QUOTA_EXCEEDED"},
+}
+
+// toStatus converts a service error into a gRPC status error. Unknown errors
+// become codes.Internal.
+func toStatus(err error) error {
+	for _, m := range errorMappings {
+		if errors.Is(err, m.target) {
+			return withReason(m.code, m.reason, err.Error())
+		}
+	}
+	return withReason(codes.Internal, "INTERNAL", err.Error())
+}
+
+func invalidArgument(msg string) error {
+	return withReason(codes.InvalidArgument, "BAD_REQUEST", msg)
+}
+
+func withReason(code codes.Code, reason, msg string) error {
+	st := status.New(code, msg)
+	detailed, err := st.WithDetails(&errdetails.ErrorInfo{Reason: reason, Domain: errorDomain})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
@@ -0,0 +1,66 @@
+package grpc
+
+import (
+	"context"
+
+	prv1 "github.com/Wucop228/avito-PullRequest/api/gen/pr/v1"
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+	"github.com/Wucop228/avito-PullRequest/internal/service"
+)
+
+type PullRequestServer struct {
+	prv1.UnimplementedPullRequestServiceServer
+
+	svc *service.PullRequestService
+}
+
+func NewPullRequestServer(svc *service.PullRequestService) *PullRequestServer {
+	return &PullRequestServer{svc: svc}
+}
+
+func (s *PullRequestServer) CreatePullRequest(ctx context.Context, req *prv1.CreatePullRequestRequest) (*prv1.CreatePullRequestResponse, error) {
+	if req.GetPullRequestId() == "" || req.GetPullRequestName() == "" || req.GetAuthorId() == "" {
+		return nil, invalidArgument("pull_request_id, pull_request_name and author_id are required")
+	}
+
+	pr, err := s.svc.CreatePullRequest(ctx, &models.RequestPullRequestCreate{
+		PullRequestID:   req.GetPullRequestId(),
+		PullRequestName: req.GetPullRequestName(),
+		AuthorID:        req.GetAuthorId(),
+		ChangedFiles:    req.GetChangedFiles(),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &prv1.CreatePullRequestResponse{Pr: pullRequestToProto(pr)}, nil
+}
+
+func (s *PullRequestServer) MergePullRequest(ctx context.Context, req *prv1.MergePullRequestRequest) (*prv1.MergePullRequestResponse, error) {
+	if req.GetPullRequestId() == "" {
+		return nil, invalidArgument("pull_request_id is required")
+	}
+
+	pr, err := s.svc.MergePullRequest(ctx, req.GetPullRequestId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &prv1.MergePullRequestResponse{Pr: pullRequestToProto(pr)}, nil
+}
+
+func (s *PullRequestServer) ReassignReviewer(ctx context.Context, req *prv1.ReassignReviewerRequest) (*prv1.ReassignReviewerResponse, error) {
+	if req.GetPullRequestId() == "" || req.GetOldUserId() == "" {
+		return nil, invalidArgument("pull_request_id and old_user_id are required")
+	}
+
+	pr, replacedBy, err := s.svc.ReassignReviewer(ctx, req.GetPullRequestId(), req.GetOldUserId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &prv1.ReassignReviewerResponse{
+		Pr:         pullRequestToProto(pr),
+		ReplacedBy: replacedBy,
+	}, nil
+}
@@ -0,0 +1,105 @@
+// Package grpc serves the pr.v1 gRPC API on top of the service layer.
+package grpc
+
+import (
+	"context"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	prv1 "github.com/Wucop228/avito-PullRequest/api/gen/pr/v1"
+	"github.com/Wucop228/avito-PullRequest/internal/logger"
+	"github.com/Wucop228/avito-PullRequest/internal/service"
+)
+
+// metadataRequestID carries the request id, like X-Request-ID over HTTP.
+const metadataRequestID = "x-request-id"
+
+// NewServer returns a gRPC server with the team, user and pull request
+// services registered. Server reflection is on, so grpcurl works without the
+// proto file. Calls are traced, with the trace context taken from the
+// traceparent metadata.
+func NewServer(teamSvc *service.TeamService, userSvc *service.UserService, prSvc *service.PullRequestService) *grpc.Server {
+	srv := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(requestIDUnary, logUnary, recoverUnary),
+	)
+
+	prv1.RegisterTeamServiceServer(srv, NewTeamServer(teamSvc))
+	prv1.RegisterUserServiceServer(srv, NewUserServer(userSvc, prSvc))
+	prv1.RegisterPullRequestServiceServer(srv, NewPullRequestServer(prSvc))
+	reflection.Register(srv)
+
+	return srv
+}
+
+// requestIDUnary does what the RequestID middleware does for HTTP, with the
+// x-request-id metadata key.
+func requestIDUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(metadataRequestID); len(v) > 0 {
+			id = v[0]
+		}
+	}
+	if !logger.ValidRequestID(id) {
+		id = logger.NewRequestID()
+	}
+
+	ctx = logger.WithRequestID(ctx, id)
+	if err := grpc.SetHeader(ctx, metadata.Pairs(metadataRequestID, id)); err != nil {
+		slog.WarnContext(ctx, "failed to set request id header", "error", err)
+	}
+
+	return handler(ctx, req)
+}
+
+// recoverUnary turns a panic in a handler into codes.Internal, like the
+// Recover middleware of the HTTP server.
+func recoverUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.ErrorContext(ctx, "panic in grpc handler",
+				"method", info.FullMethod,
+				"error", r,
+				"stack", string(debug.Stack()),
+			)
+			err = status.Error(codes.Internal, "internal error")
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// logUnary writes one line per call. Internal errors are unexpected service
+// errors and are logged with their message.
+func logUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+
+	code := status.Code(err)
+	attrs := []slog.Attr{
+		slog.String("method", info.FullMethod),
+		slog.String("code", code.String()),
+		slog.Duration("latency", time.Since(start).Round(time.Microsecond)),
+	}
+
+	level := slog.LevelInfo
+	switch code {
+	case codes.OK:
+	case codes.Internal, codes.Unknown:
+		level = slog.LevelError
+		attrs = append(attrs, slog.String("error", status.Convert(err).Message()))
+	default:
+		level = slog.LevelWarn
+	}
+
+	slog.LogAttrs(ctx, level, "grpc request", attrs...)
+	return resp, err
+}
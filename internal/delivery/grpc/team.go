@@ -0,0 +1,44 @@
+package grpc
+
+import (
+	"context"
+
+	prv1 "github.com/Wucop228/avito-PullRequest/api/gen/pr/v1"
+	"github.com/Wucop228/avito-PullRequest/internal/service"
+)
+
+type TeamServer struct {
+	prv1.UnimplementedTeamServiceServer
+
+	svc *service.TeamService
+}
+
+func NewTeamServer(svc *service.TeamService) *TeamServer {
+	return &TeamServer{svc: svc}
+}
+
+func (s *TeamServer) AddTeam(ctx context.Context, req *prv1.AddTeamRequest) (*prv1.AddTeamResponse, error) {
+	if req.GetTeam().GetTeamName() == "" {
+		return nil, invalidArgument("team.team_name is required")
+	}
+
+	team := teamFromProto(req.GetTeam())
+	if err := s.svc.CreateTeamWithMembers(ctx, team); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &prv1.AddTeamResponse{Team: teamToProto(team)}, nil
+}
+
+func (s *TeamServer) GetTeam(ctx context.Context, req *prv1.GetTeamRequest) (*prv1.GetTeamResponse, error) {
+	if req.GetTeamName() == "" {
+		return nil, invalidArgument("team_name is required")
+	}
+
+	team, err := s.svc.GetTeam(ctx, req.GetTeamName())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &prv1.GetTeamResponse{Team: teamToProto(team)}, nil
+}
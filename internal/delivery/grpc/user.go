@@ -0,0 +1,53 @@
+package grpc
+
+import (
+	"context"
+
+	prv1 "github.com/Wucop228/avito-PullRequest/api/gen/pr/v1"
+	"github.com/Wucop228/avito-PullRequest/internal/service"
+)
+
+type UserServer struct {
+	prv1.UnimplementedUserServiceServer
+
+	svc   *service.UserService
+	prSvc *service.PullRequestService
+}
+
+func NewUserServer(svc *service.UserService, prSvc *service.PullRequestService) *UserServer {
+	return &UserServer{svc: svc, prSvc: prSvc}
+}
+
+func (s *UserServer) SetIsActive(ctx context.Context, req *prv1.SetIsActiveRequest) (*prv1.SetIsActiveResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, invalidArgument("user_id is required")
+	}
+
+	user, err := s.svc.SetIsActive(ctx, req.GetUserId(), req.GetIsActive())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &prv1.SetIsActiveResponse{User: userToProto(user)}, nil
+}
+
+func (s *UserServer) GetReview(ctx context.Context, req *prv1.GetReviewRequest) (*prv1.GetReviewResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, invalidArgument("user_id is required")
+	}
+
+	prs, err := s.prSvc.GetUserReviews(ctx, req.GetUserId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	res := &prv1.GetReviewResponse{
+		UserId:       req.GetUserId(),
+		PullRequests: make([]*prv1.PullRequestShort, 0, len(prs)),
+	}
+	for _, pr := range prs {
+		res.PullRequests = append(res.PullRequests, pullRequestShortToProto(pr))
+	}
+
+	return res, nil
+}
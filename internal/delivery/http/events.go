@@ -0,0 +1,145 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/Wucop228/avito-PullRequest/internal/events"
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+	"github.com/Wucop228/avito-PullRequest/internal/service"
+)
+
+const (
+	replayBatch    = 500
+	sseKeepAlive   = 15 * time.Second
+	sseContentType = "text/event-stream"
+)
+
+type EventsHandler struct {
+	svc *service.EventService
+}
+
+func NewEventsHandler(svc *service.EventService) *EventsHandler {
+	return &EventsHandler{svc: svc}
+}
+
+// Stream sends service events as Server-Sent Events. With Last-Event-ID
+// (header or last_event_id query parameter) it first replays the events the
+// client missed. Delivery is at least once: clients should ignore ids they
+// have already seen.
+func (h *EventsHandler) Stream(c echo.Context) error {
+	filter := models.EventFilter{
+		TeamName: c.QueryParam("team_name"),
+		UserID:   c.QueryParam("user_id"),
+	}
+
+	lastEventID := c.Request().Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.QueryParam("last_event_id")
+	}
+	var afterID int64
+	if lastEventID != "" {
+		id, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || id < 0 {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"error": echo.Map{
+					"code":    "BAD_REQUEST",
+					"message": "Last-Event-ID must be a non-negative integer",
+				},
+			})
+		}
+		afterID = id
+	}
+
+	// Subscribe before replaying so that nothing published in between is
+	// lost.
+	sub, err := h.svc.Subscribe(filter)
+	if err != nil {
+		if errors.Is(err, events.ErrBrokerClosed) {
+			return c.JSON(http.StatusServiceUnavailable, echo.Map{
+				"error": echo.Map{
+					"code":    "UNAVAILABLE",
+					"message": "server is shutting down",
+				},
+			})
+		}
+
+		return internalError(c, err)
+	}
+	defer sub.Close()
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, sseContentType)
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	replayedUpTo := int64(0)
+	if lastEventID != "" {
+		for {
+			list, err := h.svc.GetEventsAfter(c.Request().Context(), afterID, filter, replayBatch)
+			if err != nil {
+				// Headers are already sent; the client reconnects and
+				// retries from its last id.
+				return nil
+			}
+			for i := range list {
+				if err := writeEvent(res, &list[i]); err != nil {
+					return nil
+				}
+				afterID = list[i].ID
+			}
+			if len(list) < replayBatch {
+				break
+			}
+		}
+		replayedUpTo = afterID
+	}
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	ctx := c.Request().Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(res, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		case e, ok := <-sub.C:
+			if !ok {
+				// Shutdown or the client fell behind.
+				return nil
+			}
+			if e.ID <= replayedUpTo {
+				continue
+			}
+			if err := writeEvent(res, &e); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+func writeEvent(res *echo.Response, e *models.StoredEvent) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(res, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data); err != nil {
+		return err
+	}
+	res.Flush()
+	return nil
+}
@@ -0,0 +1,119 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+	"github.com/Wucop228/avito-PullRequest/internal/service"
+)
+
+type GitHubHandler struct {
+	svc    *service.GitHubService
+	secret []byte
+}
+
+func NewGitHubHandler(svc *service.GitHubService, secret string) *GitHubHandler {
+	return &GitHubHandler{svc: svc, secret: []byte(secret)}
+}
+
+func (h *GitHubHandler) Webhook(c echo.Context) error {
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": echo.Map{
+				"code":    "BAD_REQUEST",
+				"message": err.Error(),
+			},
+		})
+	}
+
+	if !h.validSignature(body, c.Request().Header.Get("X-Hub-Signature-256")) {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"error": echo.Map{
+				"code":    "UNAUTHORIZED",
+				"message": "invalid webhook signature",
+			},
+		})
+	}
+
+	if c.Request().Header.Get("X-GitHub-Event") != "pull_request" {
+		return c.JSON(http.StatusOK, echo.Map{
+			"result": service.WebhookResultIgnored,
+		})
+	}
+
+	var event models.GitHubPullRequestEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": echo.Map{
+				"code":    "BAD_REQUEST",
+				"message": err.Error(),
+			},
+		})
+	}
+
+	if event.Repository.FullName == "" || event.PullRequest.User.Login == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": echo.Map{
+				"code":    "BAD_REQUEST",
+				"message": "repository.full_name and pull_request.user.login are required",
+			},
+		})
+	}
+
+	pr, result, err := h.svc.HandlePullRequestEvent(c.Request().Context(), &event)
+	if err != nil {
+		if errors.Is(err, service.ErrExternalLoginUnknown) {
+			return c.JSON(http.StatusNotFound, echo.Map{
+				"error": echo.Map{
+					"code":    "NOT_FOUND",
+					"message": "github login is not mapped to a user",
+				},
+			})
+		}
+		if errors.Is(err, service.ErrPRAlreadyMerged) {
+			return c.JSON(http.StatusConflict, echo.Map{
+				"error": echo.Map{
+					"code":    "PR_MERGED",
+					"message": "pull request is already merged",
+				},
+			})
+		}
+
+		return internalError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"result": result,
+		"pr":     pr,
+	})
+}
+
+func (h *GitHubHandler) validSignature(body []byte, header string) bool {
+	if len(h.secret) == 0 {
+		return false
+	}
+
+	signature, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, h.secret)
+	mac.Write(body)
+
+	return hmac.Equal(got, mac.Sum(nil))
+}
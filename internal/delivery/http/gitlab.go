@@ -0,0 +1,91 @@
+package http
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+	"github.com/Wucop228/avito-PullRequest/internal/service"
+)
+
+type GitLabHandler struct {
+	svc   *service.GitLabService
+	token []byte
+}
+
+func NewGitLabHandler(svc *service.GitLabService, token string) *GitLabHandler {
+	return &GitLabHandler{svc: svc, token: []byte(token)}
+}
+
+func (h *GitLabHandler) Webhook(c echo.Context) error {
+	if !h.validToken(c.Request().Header.Get("X-Gitlab-Token")) {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"error": echo.Map{
+				"code":    "UNAUTHORIZED",
+				"message": "invalid webhook token",
+			},
+		})
+	}
+
+	if c.Request().Header.Get("X-Gitlab-Event") != "Merge Request Hook" {
+		return c.JSON(http.StatusOK, echo.Map{
+			"result": service.WebhookResultIgnored,
+		})
+	}
+
+	var event models.GitLabMergeRequestEvent
+	if err := c.Bind(&event); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": echo.Map{
+				"code":    "BAD_REQUEST",
+				"message": err.Error(),
+			},
+		})
+	}
+
+	if event.Project.ID == 0 || event.ObjectAttributes.IID == 0 || event.User.Username == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": echo.Map{
+				"code":    "BAD_REQUEST",
+				"message": "project.id, object_attributes.iid and user.username are required",
+			},
+		})
+	}
+
+	pr, result, err := h.svc.HandleMergeRequestEvent(c.Request().Context(), &event)
+	if err != nil {
+		if errors.Is(err, service.ErrExternalLoginUnknown) {
+			return c.JSON(http.StatusNotFound, echo.Map{
+				"error": echo.Map{
+					"code":    "NOT_FOUND",
+					"message": "gitlab username is not mapped to a user",
+				},
+			})
+		}
+		if errors.Is(err, service.ErrPRAlreadyMerged) {
+			return c.JSON(http.StatusConflict, echo.Map{
+				"error": echo.Map{
+					"code":    "PR_MERGED",
+					"message": "pull request is already merged",
+				},
+			})
+		}
+
+		return internalError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"result": result,
+		"pr":     pr,
+	})
+}
+
+func (h *GitLabHandler) validToken(token string) bool {
+	if len(h.token) == 0 {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), h.token) == 1
+}
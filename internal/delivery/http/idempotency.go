@@ -0,0 +1,150 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+	"github.com/Wucop228/avito-PullRequest/internal/service"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// Idempotency replays the stored response for POST requests that repeat an
+// Idempotency-Key header with the same body and Authorization header. Requests
+// without the header are passed through unchanged. 5xx responses are not
+// stored so that they can be retried, and neither are 401, 403 and 429, which
+// depend on the credentials or the moment rather than on the request.
+func Idempotency(svc *service.IdempotencyService) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			key := req.Header.Get("Idempotency-Key")
+			if req.Method != http.MethodPost || key == "" {
+				return next(c)
+			}
+
+			if len(key) > maxIdempotencyKeyLength {
+				return c.JSON(http.StatusBadRequest, echo.Map{
+					"error": echo.Map{
+						"code":    "BAD_REQUEST",
+						"message": "Idempotency-Key is too long",
+					},
+				})
+			}
+
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, echo.Map{
+					"error": echo.Map{
+						"code":    "BAD_REQUEST",
+						"message": err.Error(),
+					},
+				})
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			path := c.Path()
+			// The caller is part of the fingerprint, so a key reused with
+			// another token is rejected instead of replaying someone else's
+			// response. Only a hash of the header is kept.
+			auth := sha256.Sum256([]byte(req.Header.Get(echo.HeaderAuthorization)))
+			fingerprint := append(auth[:], req.URL.RawQuery+"\n"...)
+			fingerprint = append(fingerprint, body...)
+
+			stored, err := svc.Begin(c.Request().Context(), key, req.Method, path, fingerprint)
+			if err != nil {
+				if errors.Is(err, service.ErrIdempotencyKeyReused) {
+					return c.JSON(http.StatusUnprocessableEntity, echo.Map{
+						"error": echo.Map{
+							"code":    "IDEMPOTENCY_KEY_REUSED",
+							"message": "idempotency key was used with a different request",
+						},
+					})
+				}
+				if errors.Is(err, service.ErrIdempotencyKeyInProgress) {
+					return c.JSON(http.StatusConflict, echo.Map{
+						"error": echo.Map{
+							"code":    "IDEMPOTENCY_KEY_IN_PROGRESS",
+							"message": "request with this idempotency key is in progress",
+						},
+					})
+				}
+
+				return internalError(c, err)
+			}
+
+			if stored != nil {
+				c.Response().Header().Set("Idempotent-Replayed", "true")
+				return c.Blob(stored.StatusCode, stored.ContentType, stored.Response)
+			}
+
+			release := func() {
+				if err := svc.Release(req.Context(), key, req.Method, path); err != nil {
+					slog.ErrorContext(req.Context(), "failed to release idempotency key", "key", key, "error", err)
+				}
+			}
+
+			// Recover sits outside this middleware, so a panicking handler
+			// would otherwise leave the key in progress until it expires.
+			defer func() {
+				if r := recover(); r != nil {
+					release()
+					panic(r)
+				}
+			}()
+
+			recorder := &bodyRecorder{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = recorder
+
+			if err := next(c); err != nil {
+				release()
+				return err
+			}
+
+			res := c.Response()
+			if !storableStatus(res.Status) {
+				release()
+				return nil
+			}
+
+			if err := svc.Complete(c.Request().Context(), &models.IdempotencyRecord{
+				Key:         key,
+				Method:      req.Method,
+				Path:        path,
+				StatusCode:  res.Status,
+				ContentType: res.Header().Get(echo.HeaderContentType),
+				Response:    recorder.body.Bytes(),
+			}); err != nil {
+				slog.ErrorContext(req.Context(), "failed to store idempotent response", "key", key, "error", err)
+			}
+
+			return nil
+		}
+	}
+}
+
+// storableStatus reports whether a response with status can be replayed.
+func storableStatus(status int) bool {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests:
+		return false
+	}
+	return status < http.StatusInternalServerError
+}
+
+type bodyRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *bodyRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
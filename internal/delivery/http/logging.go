@@ -0,0 +1,105 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+
+	"github.com/Wucop228/avito-PullRequest/internal/logger"
+)
+
+const HeaderRequestID = "X-Request-ID"
+
+// RequestID takes the request id from the X-Request-ID header or generates
+// one, puts it into the request context for the log lines of the request
+// and returns it in the X-Request-ID response header.
+func RequestID() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+
+			id := req.Header.Get(HeaderRequestID)
+			if !logger.ValidRequestID(id) {
+				id = logger.NewRequestID()
+			}
+
+			c.SetRequest(req.WithContext(logger.WithRequestID(req.Context(), id)))
+			c.Response().Header().Set(HeaderRequestID, id)
+
+			return next(c)
+		}
+	}
+}
+
+// RequestLogger writes one line per request. 5xx responses are logged as
+// errors, 4xx as warnings.
+func RequestLogger() echo.MiddlewareFunc {
+	return middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
+		LogMethod:       true,
+		LogURI:          true,
+		LogRoutePath:    true,
+		LogStatus:       true,
+		LogLatency:      true,
+		LogRemoteIP:     true,
+		LogResponseSize: true,
+		LogError:        true,
+		HandleError:     true,
+		LogValuesFunc: func(c echo.Context, v middleware.RequestLoggerValues) error {
+			level := slog.LevelInfo
+			switch {
+			case v.Status >= http.StatusInternalServerError:
+				level = slog.LevelError
+			case v.Status >= http.StatusBadRequest:
+				level = slog.LevelWarn
+			}
+
+			attrs := []slog.Attr{
+				slog.String("method", v.Method),
+				slog.String("uri", v.URI),
+				slog.String("route", v.RoutePath),
+				slog.Int("status", v.Status),
+				slog.Duration("latency", v.Latency.Round(time.Microsecond)),
+				slog.String("remote_ip", v.RemoteIP),
+				slog.Int64("bytes_out", v.ResponseSize),
+			}
+			if v.Error != nil {
+				attrs = append(attrs, slog.String("error", v.Error.Error()))
+			}
+
+			slog.LogAttrs(c.Request().Context(), level, "request", attrs...)
+			return nil
+		},
+	})
+}
+
+// Recover turns a panic in a handler into a 500 and logs it with the stack.
+func Recover() echo.MiddlewareFunc {
+	return middleware.RecoverWithConfig(middleware.RecoverConfig{
+		LogErrorFunc: func(c echo.Context, err error, stack []byte) error {
+			slog.ErrorContext(c.Request().Context(), "panic in handler",
+				"error", err,
+				"stack", string(stack),
+			)
+			return err
+		},
+	})
+}
+
+// internalError logs an unexpected service error and answers with 500.
+func internalError(c echo.Context, err error) error {
+	slog.ErrorContext(c.Request().Context(), "request failed",
+		"method", c.Request().Method,
+		"route", c.Path(),
+		"error", err,
+	)
+
+	return c.JSON(http.StatusInternalServerError, echo.Map{
+		"error": echo.Map{
+			"code":    "INTERNAL",
+			"message": err.Error(),
+		},
+	})
+}
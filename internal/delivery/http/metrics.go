@@ -0,0 +1,110 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/Wucop228/avito-PullRequest/internal/service"
+)
+
+// MetricsHandler exposes gauges in the Prometheus text format. Values are
+// computed from the database on every scrape; SLA gauges cover assignments
+// made within the last 30 days.
+type MetricsHandler struct {
+	svc *service.StatsService
+}
+
+func NewMetricsHandler(svc *service.StatsService) *MetricsHandler {
+	return &MetricsHandler{svc: svc}
+}
+
+func (h *MetricsHandler) Metrics(c echo.Context) error {
+	stats, err := h.svc.GetReviewStats(c.Request().Context())
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to collect metrics", "error", err)
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	to := time.Now().UTC()
+	sla, err := h.svc.GetReviewSLAReport(c.Request().Context(), to.Add(-defaultStatsWindow), to, "")
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to collect metrics", "error", err)
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	var w metricsWriter
+
+	w.family("pr_pull_requests", "Pull requests by status.")
+	w.sample("pr_pull_requests", stats.PullRequests.Open, "status", "OPEN")
+	w.sample("pr_pull_requests", stats.PullRequests.Merged, "status", "MERGED")
+	w.sample("pr_pull_requests", stats.PullRequests.Closed, "status", "CLOSED")
+
+	w.family("pr_reviewer_open_reviews", "Open pull requests assigned to the reviewer.")
+	for _, r := range stats.Reviewers {
+		w.sample("pr_reviewer_open_reviews", r.OpenReviews, "reviewer_id", r.UserID, "team_name", r.TeamName)
+	}
+
+	w.family("pr_review_sla_assignments", "Review assignments of the last 30 days by SLA result.")
+	for _, r := range sla.Reviewers {
+		w.sample("pr_review_sla_assignments", r.Met, "reviewer_id", r.ReviewerID, "team_name", r.TeamName, "result", "met")
+		w.sample("pr_review_sla_assignments", r.Breached, "reviewer_id", r.ReviewerID, "team_name", r.TeamName, "result", "breached")
+		w.sample("pr_review_sla_assignments", r.Pending, "reviewer_id", r.ReviewerID, "team_name", r.TeamName, "result", "pending")
+	}
+
+	overdue := make(map[[2]string]int)
+	for _, b := range sla.Breaches {
+		if b.EndedAt == nil {
+			overdue[[2]string{b.ReviewerID, b.TeamName}]++
+		}
+	}
+	keys := make([][2]string, 0, len(overdue))
+	for k := range overdue {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+
+	w.family("pr_review_sla_overdue", "Open review assignments past their SLA deadline.")
+	for _, k := range keys {
+		w.sample("pr_review_sla_overdue", overdue[k], "reviewer_id", k[0], "team_name", k[1])
+	}
+
+	return c.Blob(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", w.buf.Bytes())
+}
+
+type metricsWriter struct {
+	buf bytes.Buffer
+}
+
+func (w *metricsWriter) family(name, help string) {
+	fmt.Fprintf(&w.buf, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+}
+
+// sample writes one line; labels are name/value pairs.
+func (w *metricsWriter) sample(name string, value int, labels ...string) {
+	w.buf.WriteString(name)
+	if len(labels) > 0 {
+		w.buf.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				w.buf.WriteByte(',')
+			}
+			fmt.Fprintf(&w.buf, "%s=\"%s\"", labels[i], labelEscaper.Replace(labels[i+1]))
+		}
+		w.buf.WriteByte('}')
+	}
+	fmt.Fprintf(&w.buf, " %d\n", value)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
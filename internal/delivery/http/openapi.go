@@ -0,0 +1,248 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/labstack/echo/v4"
+)
+
+// RegisterRoutes adds a route for every operation of the spec, looking the
+// handler up by operationId. It fails when an operation has no handler or a
+// handler has no operation, so the routes cannot drift from the spec.
+func RegisterRoutes(e *echo.Echo, doc *openapi3.T, handlers map[string]echo.HandlerFunc) error {
+	used := make(map[string]bool, len(handlers))
+
+	for _, path := range doc.Paths.InMatchingOrder() {
+		for method, op := range doc.Paths.Value(path).Operations() {
+			if op.OperationID == "" {
+				return fmt.Errorf("%s %s has no operationId", method, path)
+			}
+
+			handler, ok := handlers[op.OperationID]
+			if !ok {
+				return fmt.Errorf("no handler for operation %s (%s %s)", op.OperationID, method, path)
+			}
+			if used[op.OperationID] {
+				return fmt.Errorf("operationId %s is used more than once", op.OperationID)
+			}
+			used[op.OperationID] = true
+
+			e.Add(method, echoPath(path), handler)
+		}
+	}
+
+	var unused []string
+	for id := range handlers {
+		if !used[id] {
+			unused = append(unused, id)
+		}
+	}
+	if len(unused) > 0 {
+		sort.Strings(unused)
+		return fmt.Errorf("handlers without an operation in the spec: %s", strings.Join(unused, ", "))
+	}
+
+	return nil
+}
+
+// echoPath turns an OpenAPI path template like /teams/{name} into the echo
+// form /teams/:name.
+func echoPath(path string) string {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(path, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(path[start:], '}')
+		if end < 0 {
+			break
+		}
+		b.WriteString(path[:start])
+		b.WriteString(":")
+		b.WriteString(path[start+1 : start+end])
+		path = path[start+end+1:]
+	}
+	b.WriteString(path)
+	return b.String()
+}
+
+// OpenAPIValidator checks requests against the spec before they reach the
+// handlers and answers those that do not match with 400. With
+// validateResponses it also buffers every response and replaces one that
+// does not match the spec with 500. That is meant for tests, where a handler
+// drifting from the contract should fail loudly; event streams are never
+// buffered.
+//
+// Webhook signatures and tokens are declared as security schemes and are
+// checked by the handlers themselves.
+func OpenAPIValidator(doc *openapi3.T, validateRequests, validateResponses bool) (echo.MiddlewareFunc, error) {
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	options := &openapi3filter.Options{
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		// Defaults would rewrite the body, which breaks webhook signatures.
+		SkipSettingDefaults:   true,
+		IncludeResponseStatus: true,
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+
+			// Anything outside the spec gets the usual 404 or 405 from echo.
+			route, pathParams, err := router.FindRoute(req)
+			if err != nil {
+				return next(c)
+			}
+
+			input := &openapi3filter.RequestValidationInput{
+				Request:    req,
+				PathParams: pathParams,
+				Route:      route,
+				Options:    options,
+			}
+
+			if validateRequests {
+				if err := openapi3filter.ValidateRequest(req.Context(), input); err != nil {
+					return c.JSON(http.StatusBadRequest, echo.Map{
+						"error": echo.Map{
+							"code":    "BAD_REQUEST",
+							"message": validationMessage(err),
+						},
+					})
+				}
+			}
+
+			if !validateResponses || streamsEvents(route) {
+				return next(c)
+			}
+
+			return validateResponse(c, next, input)
+		}
+	}, nil
+}
+
+func validateResponse(c echo.Context, next echo.HandlerFunc, input *openapi3filter.RequestValidationInput) error {
+	res := c.Response()
+	buffer := &responseBuffer{ResponseWriter: res.Writer, status: http.StatusOK}
+	res.Writer = buffer
+
+	err := next(c)
+	res.Writer = buffer.ResponseWriter
+	if !buffer.wroteHeader {
+		// Nothing was written: echo's error handler answers through the
+		// restored writer.
+		return err
+	}
+
+	verr := openapi3filter.ValidateResponse(c.Request().Context(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 buffer.status,
+		Header:                 buffer.Header(),
+		Body:                   io.NopCloser(bytes.NewReader(buffer.body.Bytes())),
+		Options:                input.Options,
+	})
+	if verr != nil {
+		slog.ErrorContext(c.Request().Context(), "response does not match the spec",
+			"method", input.Request.Method,
+			"route", input.Route.Path,
+			"status", buffer.status,
+			"error", validationMessage(verr),
+		)
+
+		body, merr := json.Marshal(echo.Map{
+			"error": echo.Map{
+				"code":    "INTERNAL",
+				"message": "response does not match the API spec: " + validationMessage(verr),
+			},
+		})
+		if merr != nil {
+			return merr
+		}
+
+		header := buffer.Header()
+		header.Del(echo.HeaderContentLength)
+		header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		res.Status = http.StatusInternalServerError
+		buffer.ResponseWriter.WriteHeader(http.StatusInternalServerError)
+		if _, werr := buffer.ResponseWriter.Write(body); werr != nil {
+			return werr
+		}
+		return err
+	}
+
+	buffer.ResponseWriter.WriteHeader(buffer.status)
+	if _, werr := buffer.ResponseWriter.Write(buffer.body.Bytes()); werr != nil {
+		return werr
+	}
+	return err
+}
+
+// streamsEvents reports whether the operation answers with an event stream,
+// which never ends on its own and so cannot be buffered.
+func streamsEvents(route *routers.Route) bool {
+	for _, res := range route.Operation.Responses.Map() {
+		if res.Value != nil && res.Value.Content.Get(sseContentType) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// validationMessage shortens kin-openapi errors, which embed the whole schema
+// and value, to where the mismatch is and why.
+func validationMessage(err error) string {
+	var schemaErr *openapi3.SchemaError
+	if !errors.As(err, &schemaErr) {
+		return err.Error()
+	}
+
+	where := "body"
+	var reqErr *openapi3filter.RequestError
+	if errors.As(err, &reqErr) && reqErr.Parameter != nil {
+		where = reqErr.Parameter.In + " parameter " + reqErr.Parameter.Name
+	}
+	if pointer := schemaErr.JSONPointer(); len(pointer) > 0 {
+		where += " at /" + strings.Join(pointer, "/")
+	}
+
+	return where + ": " + schemaErr.Reason
+}
+
+// responseBuffer holds back the status and body of a response until it has
+// been validated. Headers go straight to the wrapped writer.
+type responseBuffer struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (b *responseBuffer) WriteHeader(status int) {
+	if b.wroteHeader {
+		return
+	}
+	b.status = status
+	b.wroteHeader = true
+}
+
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	b.wroteHeader = true
+	return b.body.Write(p)
+}
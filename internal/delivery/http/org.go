@@ -0,0 +1,162 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/Wucop228/avito-PullRequest/internal/orgfile"
+	"github.com/Wucop228/avito-PullRequest/internal/service"
+)
+
+type OrgHandler struct {
+	svc *service.OrgService
+}
+
+func NewOrgHandler(svc *service.OrgService) *OrgHandler {
+	return &OrgHandler{svc: svc}
+}
+
+func (h *OrgHandler) Import(c echo.Context) error {
+	dryRun, err := boolQueryParam(c, "dry_run")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": echo.Map{
+				"code":    "BAD_REQUEST",
+				"message": err.Error(),
+			},
+		})
+	}
+
+	teams, err := orgfile.Read(c.Request().Body, orgFormat(c))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": echo.Map{
+				"code":    "BAD_REQUEST",
+				"message": err.Error(),
+			},
+		})
+	}
+
+	diff, err := h.svc.Import(c.Request().Context(), teams, dryRun)
+	if err != nil {
+		return orgError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, diff)
+}
+
+func (h *OrgHandler) Reconcile(c echo.Context) error {
+	apply, err := boolQueryParam(c, "apply")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": echo.Map{
+				"code":    "BAD_REQUEST",
+				"message": err.Error(),
+			},
+		})
+	}
+	reassign, err := boolQueryParam(c, "reassign_reviews")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": echo.Map{
+				"code":    "BAD_REQUEST",
+				"message": err.Error(),
+			},
+		})
+	}
+
+	teams, err := orgfile.Read(c.Request().Body, orgFormat(c))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": echo.Map{
+				"code":    "BAD_REQUEST",
+				"message": err.Error(),
+			},
+		})
+	}
+
+	diff, err := h.svc.Reconcile(c.Request().Context(), teams, service.ReconcileOptions{
+		Apply:           apply,
+		ReassignReviews: reassign,
+	})
+	if err != nil {
+		return orgError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, diff)
+}
+
+func (h *OrgHandler) Export(c echo.Context) error {
+	format := c.QueryParam("format")
+	if format == "" {
+		format = orgfile.FormatJSON
+	}
+	if format != orgfile.FormatJSON && format != orgfile.FormatCSV {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": echo.Map{
+				"code":    "BAD_REQUEST",
+				"message": "format must be json or csv",
+			},
+		})
+	}
+
+	teams, err := h.svc.Export(c.Request().Context())
+	if err != nil {
+		return internalError(c, err)
+	}
+
+	if format == orgfile.FormatJSON {
+		return c.JSON(http.StatusOK, orgfile.File{Teams: teams})
+	}
+
+	var buf bytes.Buffer
+	if err := orgfile.Write(&buf, orgfile.FormatCSV, teams); err != nil {
+		return internalError(c, err)
+	}
+
+	return c.Blob(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+func orgError(c echo.Context, err error) error {
+	if errors.Is(err, service.ErrInvalidOrg) {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": echo.Map{
+				"code":    "BAD_REQUEST",
+				"message": err.Error(),
+			},
+		})
+	}
+
+	return internalError(c, err)
+}
+
+// orgFormat picks the org file format from the request Content-Type,
+// defaulting to JSON.
+func orgFormat(c echo.Context) string {
+	contentType := c.Request().Header.Get(echo.HeaderContentType)
+	switch {
+	case strings.HasPrefix(contentType, "text/csv"):
+		return orgfile.FormatCSV
+	case strings.Contains(contentType, "yaml"):
+		return orgfile.FormatYAML
+	}
+	return orgfile.FormatJSON
+}
+
+func boolQueryParam(c echo.Context, name string) (bool, error) {
+	v := c.QueryParam(name)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("%s must be a boolean", name)
+	}
+	return b, nil
+}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"context"
 	"errors"
 	"net/http"
 
@@ -38,7 +39,17 @@ func (h *PullRequestHandler) Create(c echo.Context) error {
 		})
 	}
 
-	pr, err := h.svc.CreatePullRequest(&req)
+	explain, err := boolQueryParam(c, "explain")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": echo.Map{
+				"code":    "BAD_REQUEST",
+				"message": err.Error(),
+			},
+		})
+	}
+
+	pr, explanation, err := h.svc.CreatePullRequestWithExplanation(c.Request().Context(), &req)
 	if err != nil {
 		if errors.Is(err, service.ErrAuthorNotFound) {
 			return c.JSON(http.StatusNotFound, echo.Map{
@@ -57,17 +68,60 @@ func (h *PullRequestHandler) Create(c echo.Context) error {
 			})
 		}
 
-		return c.JSON(http.StatusInternalServerError, echo.Map{
+		return internalError(c, err)
+	}
+
+	res := echo.Map{
+		"pr": pr,
+	}
+	if explain {
+		res["explanation"] = explanation
+	}
+	return c.JSON(http.StatusCreated, res)
+}
+
+func (h *PullRequestHandler) Get(c echo.Context) error {
+	prID := c.QueryParam("pull_request_id")
+	if prID == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{
 			"error": echo.Map{
-				"code":    "INTERNAL",
+				"code":    "BAD_REQUEST",
+				"message": "pull_request_id is required",
+			},
+		})
+	}
+
+	explain, err := boolQueryParam(c, "explain")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": echo.Map{
+				"code":    "BAD_REQUEST",
 				"message": err.Error(),
 			},
 		})
 	}
 
-	return c.JSON(http.StatusCreated, echo.Map{
+	pr, explanations, err := h.svc.GetPullRequest(c.Request().Context(), prID, explain)
+	if err != nil {
+		if errors.Is(err, service.ErrPRNotFound) {
+			return c.JSON(http.StatusNotFound, echo.Map{
+				"error": echo.Map{
+					"code":    "NOT_FOUND",
+					"message": "pull request not found",
+				},
+			})
+		}
+
+		return internalError(c, err)
+	}
+
+	res := echo.Map{
 		"pr": pr,
-	})
+	}
+	if explain {
+		res["explanations"] = explanations
+	}
+	return c.JSON(http.StatusOK, res)
 }
 
 func (h *PullRequestHandler) Merge(c echo.Context) error {
@@ -89,7 +143,7 @@ func (h *PullRequestHandler) Merge(c echo.Context) error {
 		})
 	}
 
-	pr, err := h.svc.MergePullRequest(req.PullRequestID)
+	pr, err := h.svc.MergePullRequest(c.Request().Context(), req.PullRequestID)
 	if err != nil {
 		if errors.Is(err, service.ErrPRNotFound) {
 			return c.JSON(http.StatusNotFound, echo.Map{
@@ -100,12 +154,7 @@ func (h *PullRequestHandler) Merge(c echo.Context) error {
 			})
 		}
 
-		return c.JSON(http.StatusInternalServerError, echo.Map{
-			"error": echo.Map{
-				"code":    "INTERNAL",
-				"message": err.Error(),
-			},
-		})
+		return internalError(c, err)
 	}
 
 	return c.JSON(http.StatusOK, echo.Map{
@@ -123,16 +172,36 @@ func (h *PullRequestHandler) Reassign(c echo.Context) error {
 			},
 		})
 	}
-	if req.PullRequestID == "" || req.OldUserID == "" {
+	if req.PullRequestID == "" || (req.OldUserID == "" && req.NewUserID == "") {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": echo.Map{
+				"code":    "BAD_REQUEST",
+				"message": "pull_request_id and old_user_id or new_user_id are required",
+			},
+		})
+	}
+
+	explain, err := boolQueryParam(c, "explain")
+	if err != nil {
 		return c.JSON(http.StatusBadRequest, echo.Map{
 			"error": echo.Map{
 				"code":    "BAD_REQUEST",
-				"message": "pull_request_id and old_user_id are required",
+				"message": err.Error(),
 			},
 		})
 	}
 
-	pr, replacedBy, err := h.svc.ReassignReviewer(req.PullRequestID, req.OldUserID)
+	var (
+		pr          *models.PullRequest
+		replacedBy  string
+		explanation *models.AssignmentExplanation
+	)
+	if req.NewUserID != "" {
+		pr, explanation, err = h.svc.ReassignReviewerTo(c.Request().Context(), req.PullRequestID, req.OldUserID, req.NewUserID)
+		replacedBy = req.NewUserID
+	} else {
+		pr, replacedBy, explanation, err = h.svc.ReassignReviewerWithExplanation(c.Request().Context(), req.PullRequestID, req.OldUserID)
+	}
 	if err != nil {
 		if errors.Is(err, service.ErrPRNotFound) || errors.Is(err, service.ErrUserNotFound) {
 			return c.JSON(http.StatusNotFound, echo.Map{
@@ -167,41 +236,184 @@ func (h *PullRequestHandler) Reassign(c echo.Context) error {
 			})
 		}
 
-		return c.JSON(http.StatusInternalServerError, echo.Map{
+		return reviewerChangeError(c, err)
+	}
+
+	res := echo.Map{
+		"pr":               pr,
+		"replaced_user_id": explanation.ReplacedUserID,
+		"replaced_by":      replacedBy,
+	}
+	if explain {
+		res["explanation"] = explanation
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *PullRequestHandler) AddReviewer(c echo.Context) error {
+	return h.changeReviewer(c, h.svc.AddReviewer)
+}
+
+func (h *PullRequestHandler) RemoveReviewer(c echo.Context) error {
+	return h.changeReviewer(c, h.svc.RemoveReviewer)
+}
+
+func (h *PullRequestHandler) changeReviewer(c echo.Context, change func(ctx context.Context, prID, userID string) (*models.PullRequest, *models.AssignmentExplanation, error)) error {
+	var req models.RequestPullRequestReviewer
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
 			"error": echo.Map{
-				"code":    "INTERNAL",
+				"code":    "BAD_REQUEST",
 				"message": err.Error(),
 			},
 		})
 	}
+	if req.PullRequestID == "" || req.UserID == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": echo.Map{
+				"code":    "BAD_REQUEST",
+				"message": "pull_request_id and user_id are required",
+			},
+		})
+	}
 
-	return c.JSON(http.StatusOK, echo.Map{
-		"pr":          pr,
-		"replaced_by": replacedBy,
-	})
+	explain, err := boolQueryParam(c, "explain")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": echo.Map{
+				"code":    "BAD_REQUEST",
+				"message": err.Error(),
+			},
+		})
+	}
+
+	pr, explanation, err := change(c.Request().Context(), req.PullRequestID, req.UserID)
+	if err != nil {
+		return reviewerChangeError(c, err)
+	}
+
+	res := echo.Map{
+		"pr": pr,
+	}
+	if explain {
+		res["explanation"] = explanation
+	}
+	return c.JSON(http.StatusOK, res)
 }
 
-func (h *PullRequestHandler) GetUserReviews(c echo.Context) error {
-	userID := c.QueryParam("user_id")
-	if userID == "" {
+func (h *PullRequestHandler) TopUp(c echo.Context) error {
+	var req models.RequestPullRequestTopUp
+	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, echo.Map{
 			"error": echo.Map{
 				"code":    "BAD_REQUEST",
-				"message": "user_id is required",
+				"message": err.Error(),
+			},
+		})
+	}
+	if req.PullRequestID == "" || req.Reviewers < 1 {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": echo.Map{
+				"code":    "BAD_REQUEST",
+				"message": "pull_request_id and a positive reviewers count are required",
 			},
 		})
 	}
 
-	prs, err := h.svc.GetUserReviews(userID)
+	explain, err := boolQueryParam(c, "explain")
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{
+		return c.JSON(http.StatusBadRequest, echo.Map{
 			"error": echo.Map{
-				"code":    "INTERNAL",
+				"code":    "BAD_REQUEST",
 				"message": err.Error(),
 			},
 		})
 	}
 
+	pr, added, explanation, err := h.svc.TopUpReviewers(c.Request().Context(), req.PullRequestID, req.Reviewers)
+	if err != nil {
+		if errors.Is(err, service.ErrNoCandidate) {
+			return c.JSON(http.StatusConflict, echo.Map{
+				"error": echo.Map{
+					"code":    "NO_CANDIDATE",
+					"message": "no active reviewer candidate in team",
+				},
+			})
+		}
+
+		return reviewerChangeError(c, err)
+	}
+
+	res := echo.Map{
+		"pr":    pr,
+		"added": added,
+	}
+	if explain {
+		res["explanation"] = explanation
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// reviewerChangeError answers the errors shared by the ways reviewers of a
+// PR are changed.
+func reviewerChangeError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrPRNotFound), errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrAuthorNotFound):
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"error": echo.Map{
+				"code":    "NOT_FOUND",
+				"message": "resource not found",
+			},
+		})
+	case errors.Is(err, service.ErrPRNotOpen):
+		return c.JSON(http.StatusConflict, echo.Map{
+			"error": echo.Map{
+				"code":    "PR_NOT_OPEN",
+				"message": "reviewers can only be changed on an open PR",
+			},
+		})
+	case errors.Is(err, service.ErrReviewerNotAssigned):
+		return c.JSON(http.StatusConflict, echo.Map{
+			"error": echo.Map{
+				"code":    "NOT_ASSIGNED",
+				"message": "reviewer is not assigned to this PR",
+			},
+		})
+	case errors.Is(err, service.ErrReviewerAssigned):
+		return c.JSON(http.StatusConflict, echo.Map{
+			"error": echo.Map{
+				"code":    "ALREADY_ASSIGNED",
+				"message": "reviewer is already assigned to this PR",
+			},
+		})
+	case errors.Is(err, service.ErrReviewerIsAuthor), errors.Is(err, service.ErrReviewerInactive):
+		return c.JSON(http.StatusConflict, echo.Map{
+			"error": echo.Map{
+				"code":    "INVALID_REVIEWER",
+				"message": err.Error(),
+			},
+		})
+	}
+
+	return internalError(c, err)
+}
+
+func (h *PullRequestHandler) GetUserReviews(c echo.Context) error {
+	userID := c.QueryParam("user_id")
+	if userID == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": echo.Map{
+				"code":    "BAD_REQUEST",
+				"message": "user_id is required",
+			},
+		})
+	}
+
+	prs, err := h.svc.GetUserReviews(c.Request().Context(), userID)
+	if err != nil {
+		return internalError(c, err)
+	}
+
 	return c.JSON(http.StatusOK, echo.Map{
 		"user_id":       userID,
 		"pull_requests": prs,
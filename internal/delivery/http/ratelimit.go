@@ -0,0 +1,97 @@
+package http
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/labstack/echo/v4"
+
+	"github.com/Wucop228/avito-PullRequest/internal/ratelimit"
+)
+
+// RateLimit answers requests over the limit of their operation with 429 and
+// a Retry-After header. Limits are looked up by the operationId of the route
+// in the spec, and every limited response carries the RateLimit-* headers.
+//
+// Clients are told apart by IP. When the limiter store fails the request is
+// let through.
+func RateLimit(doc *openapi3.T, limiter *ratelimit.Limiter) (echo.MiddlewareFunc, error) {
+	operations := make(map[string]string)
+	for _, path := range doc.Paths.InMatchingOrder() {
+		for method, op := range doc.Paths.Value(path).Operations() {
+			operations[method+" "+echoPath(path)] = op.OperationID
+		}
+	}
+
+	known := make(map[string]bool, len(operations))
+	for _, id := range operations {
+		known[id] = true
+	}
+	var unknown []string
+	for _, id := range limiter.Routes() {
+		if !known[id] {
+			unknown = append(unknown, id)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("rate limits for operations not in the spec: %s", strings.Join(unknown, ", "))
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			operation := operations[req.Method+" "+c.Path()]
+
+			res, err := limiter.Allow(req.Context(), operation, rateLimitClient(c))
+			if err != nil {
+				slog.ErrorContext(req.Context(), "rate limiter failed, letting the request through",
+					"route", c.Path(),
+					"error", err,
+				)
+				return next(c)
+			}
+			if res.Limit.Requests == 0 {
+				return next(c)
+			}
+
+			header := c.Response().Header()
+			header.Set("RateLimit-Limit", strconv.Itoa(res.Limit.Burst))
+			header.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			header.Set("RateLimit-Reset", ceilSeconds(res.Reset))
+			header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%s", res.Limit.Requests, ceilSeconds(res.Limit.Per)))
+
+			if !res.Allowed {
+				header.Set("Retry-After", ceilSeconds(res.RetryAfter))
+				return c.JSON(http.StatusTooManyRequests, echo.Map{
+					"error": echo.Map{
+						"code":    "RATE_LIMITED",
+						"message": "too many requests, retry in " + ceilSeconds(res.RetryAfter) + "s",
+					},
+				})
+			}
+
+			return next(c)
+		}
+	}, nil
+}
+
+// rateLimitClient names the client of the request by its IP. Tokens are not
+// checked at this point, so keying on them would let a client reset its
+// budget by sending a new one.
+func rateLimitClient(c echo.Context) string {
+	return "ip:" + c.RealIP()
+}
+
+// ceilSeconds formats d in whole seconds, rounded up so that a client
+// waiting that long is not limited again.
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
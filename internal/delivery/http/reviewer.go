@@ -0,0 +1,109 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+	"github.com/Wucop228/avito-PullRequest/internal/service"
+)
+
+// maxDeclineReason is the longest reason a review can be declined with, in
+// characters.
+const maxDeclineReason = 500
+
+// ReviewerHandler serves the endpoints reviewers call themselves. They
+// authenticate with an API token issued by prctl.
+type ReviewerHandler struct {
+	users *service.UserService
+	prs   *service.PullRequestService
+}
+
+func NewReviewerHandler(users *service.UserService, prs *service.PullRequestService) *ReviewerHandler {
+	return &ReviewerHandler{users: users, prs: prs}
+}
+
+func (h *ReviewerHandler) Decline(c echo.Context) error {
+	user, err := h.authenticate(c)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidToken) {
+			return c.JSON(http.StatusUnauthorized, echo.Map{
+				"error": echo.Map{
+					"code":    "UNAUTHORIZED",
+					"message": err.Error(),
+				},
+			})
+		}
+
+		return internalError(c, err)
+	}
+
+	var req models.RequestReviewDecline
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": echo.Map{
+				"code":    "BAD_REQUEST",
+				"message": err.Error(),
+			},
+		})
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.PullRequestID == "" || req.Reason == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": echo.Map{
+				"code":    "BAD_REQUEST",
+				"message": "pull_request_id and reason are required",
+			},
+		})
+	}
+	if utf8.RuneCountInString(req.Reason) > maxDeclineReason {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": echo.Map{
+				"code":    "BAD_REQUEST",
+				"message": "reason must be at most 500 characters",
+			},
+		})
+	}
+
+	pr, replacedBy, left, err := h.prs.DeclineReview(c.Request().Context(), req.PullRequestID, user.UserID, req.Reason)
+	if err != nil {
+		if errors.Is(err, service.ErrDeclineQuotaExceeded) {
+			return c.JSON(http.StatusConflict, echo.Map{
+				"error": echo.Map{
+					"code":    "DECLINE_QUOTA_EXCEEDED",
+					"message": "monthly decline quota exceeded",
+				},
+			})
+		}
+		if errors.Is(err, service.ErrNoCandidate) {
+			return c.JSON(http.StatusConflict, echo.Map{
+				"error": echo.Map{
+					"code":    "NO_CANDIDATE",
+					"message": "no active replacement candidate in team",
+				},
+			})
+		}
+
+		return reviewerChangeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"pr":            pr,
+		"replaced_by":   replacedBy,
+		"declines_left": left,
+	})
+}
+
+// authenticate returns the user whose token the request carries in the
+// Authorization header.
+func (h *ReviewerHandler) authenticate(c echo.Context) (*models.User, error) {
+	token, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+	if !ok || token == "" {
+		return nil, service.ErrInvalidToken
+	}
+	return h.users.Authenticate(c.Request().Context(), token)
+}
@@ -0,0 +1,111 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/Wucop228/avito-PullRequest/internal/service"
+)
+
+const defaultStatsWindow = 30 * 24 * time.Hour
+
+type StatsHandler struct {
+	svc *service.StatsService
+}
+
+func NewStatsHandler(svc *service.StatsService) *StatsHandler {
+	return &StatsHandler{svc: svc}
+}
+
+func (h *StatsHandler) PullRequests(c echo.Context) error {
+	from, to, err := statsWindow(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": echo.Map{
+				"code":    "BAD_REQUEST",
+				"message": err.Error(),
+			},
+		})
+	}
+
+	stats, err := h.svc.GetPullRequestStats(c.Request().Context(), from, to)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidWindow) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"error": echo.Map{
+					"code":    "BAD_REQUEST",
+					"message": err.Error(),
+				},
+			})
+		}
+
+		return internalError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, stats)
+}
+
+// ReviewSLA reports review SLA compliance for assignments made within the
+// window, optionally for one team.
+func (h *StatsHandler) ReviewSLA(c echo.Context) error {
+	from, to, err := statsWindow(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": echo.Map{
+				"code":    "BAD_REQUEST",
+				"message": err.Error(),
+			},
+		})
+	}
+
+	report, err := h.svc.GetReviewSLAReport(c.Request().Context(), from, to, c.QueryParam("team_name"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidWindow) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"error": echo.Map{
+					"code":    "BAD_REQUEST",
+					"message": err.Error(),
+				},
+			})
+		}
+		if errors.Is(err, service.ErrTeamNotFound) {
+			return c.JSON(http.StatusNotFound, echo.Map{
+				"error": echo.Map{
+					"code":    "NOT_FOUND",
+					"message": "team not found",
+				},
+			})
+		}
+
+		return internalError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// statsWindow reads the from and to RFC 3339 query parameters. The window
+// ends now and spans 30 days unless given.
+func statsWindow(c echo.Context) (time.Time, time.Time, error) {
+	to := time.Now().UTC()
+	if v := c.QueryParam("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("to must be an RFC 3339 timestamp")
+		}
+		to = t
+	}
+
+	from := to.Add(-defaultStatsWindow)
+	if v := c.QueryParam("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("from must be an RFC 3339 timestamp")
+		}
+		from = t
+	}
+
+	return from, to, nil
+}
@@ -37,7 +37,7 @@ func (h *TeamHandler) TeamAdd(c echo.Context) error {
 		})
 	}
 
-	if err := h.svc.CreateTeamWithMembers(&req); err != nil {
+	if err := h.svc.CreateTeamWithMembers(c.Request().Context(), &req); err != nil {
 		if errors.Is(err, service.ErrTeamExists) {
 			return c.JSON(http.StatusBadRequest, echo.Map{
 				"error": echo.Map{
@@ -47,20 +47,97 @@ func (h *TeamHandler) TeamAdd(c echo.Context) error {
 			})
 		}
 
-		return c.JSON(http.StatusInternalServerError, echo.Map{
+		return internalError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, echo.Map{
+		"team": req,
+	})
+}
+
+func (h *TeamHandler) TeamGet(c echo.Context) error {
+	teamName := c.QueryParam("team_name")
+	if teamName == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{
 			"error": echo.Map{
-				"code":    "INTERNAL",
+				"code":    "BAD_REQUEST",
+				"message": "team_name is required",
+			},
+		})
+	}
+
+	team, err := h.svc.GetTeam(c.Request().Context(), teamName)
+	if err != nil {
+		if errors.Is(err, service.ErrTeamNotFound) {
+			return c.JSON(http.StatusNotFound, echo.Map{
+				"error": echo.Map{
+					"code":    "NOT_FOUND",
+					"message": "team not found",
+				},
+			})
+		}
+
+		return internalError(c, err)
+	}
+	
+	return c.JSON(http.StatusOK, team)
+}
+
+func (h *TeamHandler) SetCodeOwners(c echo.Context) error {
+	var req models.RequestTeamSetCodeOwners
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": echo.Map{
+				"code":    "BAD_REQUEST",
 				"message": err.Error(),
 			},
 		})
 	}
 
-	return c.JSON(http.StatusCreated, echo.Map{
-		"team": req,
+	if req.TeamName == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": echo.Map{
+				"code":    "BAD_REQUEST",
+				"message": "team_name is required",
+			},
+		})
+	}
+
+	if err := h.svc.SetCodeOwners(c.Request().Context(), &req); err != nil {
+		if errors.Is(err, service.ErrTeamNotFound) {
+			return c.JSON(http.StatusNotFound, echo.Map{
+				"error": echo.Map{
+					"code":    "NOT_FOUND",
+					"message": "team not found",
+				},
+			})
+		}
+		if errors.Is(err, service.ErrUserNotFound) {
+			return c.JSON(http.StatusNotFound, echo.Map{
+				"error": echo.Map{
+					"code":    "NOT_FOUND",
+					"message": err.Error(),
+				},
+			})
+		}
+		if errors.Is(err, service.ErrBadPattern) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"error": echo.Map{
+					"code":    "BAD_REQUEST",
+					"message": err.Error(),
+				},
+			})
+		}
+
+		return internalError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"code_owners": req,
 	})
 }
 
-func (h *TeamHandler) TeamGet(c echo.Context) error {
+func (h *TeamHandler) GetCodeOwners(c echo.Context) error {
 	teamName := c.QueryParam("team_name")
 	if teamName == "" {
 		return c.JSON(http.StatusBadRequest, echo.Map{
@@ -71,7 +148,7 @@ func (h *TeamHandler) TeamGet(c echo.Context) error {
 		})
 	}
 
-	team, err := h.svc.GetTeam(teamName)
+	codeOwners, err := h.svc.GetCodeOwners(c.Request().Context(), teamName)
 	if err != nil {
 		if errors.Is(err, service.ErrTeamNotFound) {
 			return c.JSON(http.StatusNotFound, echo.Map{
@@ -82,13 +159,82 @@ func (h *TeamHandler) TeamGet(c echo.Context) error {
 			})
 		}
 
-		return c.JSON(http.StatusInternalServerError, echo.Map{
+		return internalError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, codeOwners)
+}
+
+func (h *TeamHandler) SetSettings(c echo.Context) error {
+	var req models.TeamSettings
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
 			"error": echo.Map{
-				"code":    "INTERNAL",
+				"code":    "BAD_REQUEST",
 				"message": err.Error(),
 			},
 		})
 	}
-	
-	return c.JSON(http.StatusOK, team)
+
+	if req.TeamName == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": echo.Map{
+				"code":    "BAD_REQUEST",
+				"message": "team_name is required",
+			},
+		})
+	}
+
+	if err := h.svc.SetSettings(c.Request().Context(), &req); err != nil {
+		if errors.Is(err, service.ErrTeamNotFound) {
+			return c.JSON(http.StatusNotFound, echo.Map{
+				"error": echo.Map{
+					"code":    "NOT_FOUND",
+					"message": "team not found",
+				},
+			})
+		}
+		if errors.Is(err, service.ErrBadSettings) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"error": echo.Map{
+					"code":    "BAD_REQUEST",
+					"message": err.Error(),
+				},
+			})
+		}
+
+		return internalError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"settings": req,
+	})
+}
+
+func (h *TeamHandler) GetSettings(c echo.Context) error {
+	teamName := c.QueryParam("team_name")
+	if teamName == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": echo.Map{
+				"code":    "BAD_REQUEST",
+				"message": "team_name is required",
+			},
+		})
+	}
+
+	settings, err := h.svc.GetSettings(c.Request().Context(), teamName)
+	if err != nil {
+		if errors.Is(err, service.ErrTeamNotFound) {
+			return c.JSON(http.StatusNotFound, echo.Map{
+				"error": echo.Map{
+					"code":    "NOT_FOUND",
+					"message": "team not found",
+				},
+			})
+		}
+
+		return internalError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, settings)
 }
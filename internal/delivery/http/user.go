@@ -38,7 +38,7 @@ func (h *UserHandler) SetIsActive(c echo.Context) error {
 		})
 	}
 
-	user, err := h.svc.SetIsActive(req.UserID, req.IsActive)
+	user, err := h.svc.SetIsActive(c.Request().Context(), req.UserID, req.IsActive)
 	if err != nil {
 		if errors.Is(err, service.ErrUserNotFound) {
 			return c.JSON(http.StatusNotFound, echo.Map{
@@ -49,12 +49,7 @@ func (h *UserHandler) SetIsActive(c echo.Context) error {
 			})
 		}
 
-		return c.JSON(http.StatusInternalServerError, echo.Map{
-			"error": echo.Map{
-				"code":    "INTERNAL",
-				"message": err.Error(),
-			},
-		})
+		return internalError(c, err)
 	}
 
 	return c.JSON(http.StatusOK, echo.Map{
@@ -66,3 +61,49 @@ func (h *UserHandler) SetIsActive(c echo.Context) error {
 		},
 	})
 }
+
+func (h *UserHandler) SetExternalLogin(c echo.Context) error {
+	var req models.ExternalAccount
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": echo.Map{
+				"code":    "BAD_REQUEST",
+				"message": err.Error(),
+			},
+		})
+	}
+
+	if req.UserID == "" || req.Provider == "" || req.Login == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": echo.Map{
+				"code":    "BAD_REQUEST",
+				"message": "user_id, provider and login are required",
+			},
+		})
+	}
+
+	if err := h.svc.SetExternalLogin(c.Request().Context(), &req); err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			return c.JSON(http.StatusNotFound, echo.Map{
+				"error": echo.Map{
+					"code":    "NOT_FOUND",
+					"message": "user not found",
+				},
+			})
+		}
+		if errors.Is(err, service.ErrUnknownProvider) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"error": echo.Map{
+					"code":    "BAD_REQUEST",
+					"message": "unknown provider",
+				},
+			})
+		}
+
+		return internalError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"account": req,
+	})
+}
@@ -0,0 +1,218 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+	"github.com/Wucop228/avito-PullRequest/internal/repo"
+)
+
+const (
+	pollBatch = 500
+	// subscriberBuffer is how far a subscriber may fall behind before it is
+	// dropped; the client is expected to reconnect with Last-Event-ID.
+	subscriberBuffer = 256
+	// gapTimeout is how long the broker waits for an event id that was
+	// skipped, since ids from concurrent inserts may commit out of order.
+	gapTimeout = 5 * time.Second
+)
+
+var ErrBrokerClosed = errors.New("event broker is closed")
+
+// Broker polls the event log and fans new events out to subscribers. Polling
+// the table rather than relying on local publishes makes events written by
+// other replicas visible too.
+type Broker struct {
+	db       *sql.DB
+	interval time.Duration
+
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	closed bool
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+type Subscription struct {
+	// C is closed when the subscription ends: on Close, on broker shutdown
+	// or when the subscriber fell too far behind.
+	C <-chan models.StoredEvent
+
+	c      chan models.StoredEvent
+	filter models.EventFilter
+	broker *Broker
+}
+
+func NewBroker(db *sql.DB, interval time.Duration) *Broker {
+	return &Broker{
+		db:       db,
+		interval: interval,
+		subs:     make(map[*Subscription]struct{}),
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start begins polling from the current end of the log.
+func (b *Broker) Start() error {
+	lastID, err := repo.GetLastEventID(context.Background(), b.db)
+	if err != nil {
+		return err
+	}
+
+	go b.run(lastID)
+	return nil
+}
+
+// Notify makes the broker poll right away.
+func (b *Broker) Notify() {
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (b *Broker) Subscribe(filter models.EventFilter) (*Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, ErrBrokerClosed
+	}
+
+	c := make(chan models.StoredEvent, subscriberBuffer)
+	sub := &Subscription{C: c, c: c, filter: filter, broker: b}
+	b.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.drop(s)
+}
+
+// drop must be called with mu held.
+func (b *Broker) drop(sub *Subscription) {
+	if _, ok := b.subs[sub]; ok {
+		delete(b.subs, sub)
+		close(sub.c)
+	}
+}
+
+// Close ends every subscription and stops polling. It waits for a poll in
+// progress to finish.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	for sub := range b.subs {
+		b.drop(sub)
+	}
+	b.mu.Unlock()
+
+	close(b.stop)
+	<-b.done
+}
+
+func (b *Broker) run(lastID int64) {
+	defer close(b.done)
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	// lastID is the id up to which every event has been delivered. seen
+	// holds delivered ids above it while an earlier id is still missing.
+	seen := make(map[int64]struct{})
+	var gapSince time.Time
+
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+		case <-b.wake:
+		}
+
+		if err := b.poll(lastID, seen); err != nil {
+			slog.Error("event log poll failed", "error", err)
+		}
+
+		for {
+			if _, ok := seen[lastID+1]; !ok {
+				break
+			}
+			delete(seen, lastID+1)
+			lastID++
+		}
+
+		switch {
+		case len(seen) == 0:
+			gapSince = time.Time{}
+		case gapSince.IsZero():
+			gapSince = time.Now()
+		case time.Since(gapSince) > gapTimeout:
+			// The missing ids belong to rolled back inserts.
+			for id := range seen {
+				if id > lastID {
+					lastID = id
+				}
+			}
+			seen = make(map[int64]struct{})
+			gapSince = time.Time{}
+		}
+	}
+}
+
+// poll delivers every event after lastID that is not in seen and adds it
+// there.
+func (b *Broker) poll(lastID int64, seen map[int64]struct{}) error {
+	cursor := lastID
+	for {
+		list, err := repo.GetEventsAfter(context.Background(), b.db, cursor, models.EventFilter{}, pollBatch)
+		if err != nil {
+			return err
+		}
+
+		for i := range list {
+			if _, ok := seen[list[i].ID]; ok {
+				continue
+			}
+			b.deliver(&list[i])
+			seen[list[i].ID] = struct{}{}
+		}
+
+		if len(list) < pollBatch {
+			return nil
+		}
+		cursor = list[len(list)-1].ID
+	}
+}
+
+func (b *Broker) deliver(e *models.StoredEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subs {
+		if !sub.filter.Match(e) {
+			continue
+		}
+		select {
+		case sub.c <- *e:
+		default:
+			slog.Warn("dropping slow event subscriber", "event_id", e.ID)
+			b.drop(sub)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Package events delivers service events such as created or stale pull
+// requests to logs, webhooks, the persisted event log and its subscribers.
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Wucop228/avito-PullRequest/internal/logger"
+)
+
+var tracer = otel.Tracer("github.com/Wucop228/avito-PullRequest/internal/events")
+
+const (
+	TypePullRequestCreated = "pr.created"
+	TypePullRequestMerged  = "pr.merged"
+	TypePullRequestStale   = "pr.stale"
+	TypeReviewerAssigned   = "pr.reviewer_assigned"
+	TypeReviewerReassigned = "pr.reviewer_reassigned"
+	TypeReviewerRemoved    = "pr.reviewer_removed"
+)
+
+type Event struct {
+	Type       string    `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+	TeamName   string    `json:"team_name,omitempty"`
+	UserIDs    []string  `json:"user_ids,omitempty"`
+	Payload    any       `json:"payload"`
+}
+
+func New(eventType, teamName string, userIDs []string, payload any) Event {
+	return Event{
+		Type:       eventType,
+		OccurredAt: time.Now().UTC(),
+		TeamName:   teamName,
+		UserIDs:    userIDs,
+		Payload:    payload,
+	}
+}
+
+type Sink interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// LogSink writes every event to the default logger.
+type LogSink struct{}
+
+func (LogSink) Publish(ctx context.Context, event Event) error {
+	slog.InfoContext(ctx, "event",
+		"type", event.Type,
+		"team_name", event.TeamName,
+		"user_ids", event.UserIDs,
+		"payload", event.Payload,
+	)
+	return nil
+}
+
+// WebhookSink POSTs every event as JSON to a URL.
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookSink(url string) *WebhookSink {
+	return &WebhookSink{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Publish passes the request id and the trace context on to the receiver.
+func (s *WebhookSink) Publish(ctx context.Context, event Event) error {
+	ctx, span := tracer.Start(ctx, "events.webhook",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("event.type", event.Type)),
+	)
+	defer span.End()
+
+	if err := s.post(ctx, event); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	return nil
+}
+
+func (s *WebhookSink) post(ctx context.Context, event Event) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if id := logger.RequestID(ctx); id != "" {
+		req.Header.Set("X-Request-ID", id)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("events webhook responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// BestEffortSink logs the errors of an optional sink instead of returning
+// them, so that a caller does not publish again an event that other sinks
+// already took.
+type BestEffortSink struct {
+	Sink Sink
+}
+
+func (s BestEffortSink) Publish(ctx context.Context, event Event) error {
+	if err := s.Sink.Publish(ctx, event); err != nil {
+		slog.WarnContext(ctx, "optional event delivery failed", "type", event.Type, "error", err)
+	}
+	return nil
+}
+
+// MultiSink publishes to every sink and joins their errors.
+type MultiSink []Sink
+
+func (m MultiSink) Publish(ctx context.Context, event Event) error {
+	var errs []error
+	for _, sink := range m {
+		if err := sink.Publish(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
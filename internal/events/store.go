@@ -0,0 +1,46 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+	"github.com/Wucop228/avito-PullRequest/internal/repo"
+)
+
+// StoreSink appends every event to the persisted event log that backs the
+// /events stream.
+type StoreSink struct {
+	db *sql.DB
+	// onStored, when set, is called after each insert, e.g. to wake the
+	// local Broker without waiting for its next poll.
+	onStored func()
+}
+
+func NewStoreSink(db *sql.DB, onStored func()) *StoreSink {
+	return &StoreSink{db: db, onStored: onStored}
+}
+
+func (s *StoreSink) Publish(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event.Payload)
+	if err != nil {
+		return err
+	}
+
+	stored := &models.StoredEvent{
+		Type:       event.Type,
+		OccurredAt: event.OccurredAt,
+		TeamName:   event.TeamName,
+		UserIDs:    event.UserIDs,
+		Payload:    payload,
+	}
+	if err := repo.InsertEvent(ctx, s.db, stored); err != nil {
+		return err
+	}
+
+	if s.onStored != nil {
+		s.onStored()
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+// Package migrator applies the embedded migrations. It keeps the
+// schema_migrations table of the migrate CLI, so databases migrated either
+// way stay compatible.
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+
+	"github.com/Wucop228/avito-PullRequest/migrations"
+)
+
+type Migrator struct {
+	conn *sql.Conn
+	m    *migrate.Migrate
+}
+
+// New prepares a migrator on a dedicated connection from db. Every operation
+// holds a Postgres advisory lock, so replicas migrating at the same time wait
+// for each other instead of applying the same migration twice.
+func New(db *sql.DB) (*Migrator, error) {
+	ctx := context.Background()
+
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, "postgres", driver)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &Migrator{conn: conn, m: m}, nil
+}
+
+// Up applies all pending migrations.
+func (m *Migrator) Up() error {
+	return ignoreNoChange(m.m.Up())
+}
+
+// Down rolls back the given number of migrations.
+func (m *Migrator) Down(steps int) error {
+	return ignoreNoChange(m.m.Steps(-steps))
+}
+
+// Steps applies n migrations, rolling back when n is negative.
+func (m *Migrator) Steps(n int) error {
+	return ignoreNoChange(m.m.Steps(n))
+}
+
+// Goto migrates up or down to the given version.
+func (m *Migrator) Goto(version uint) error {
+	return ignoreNoChange(m.m.Migrate(version))
+}
+
+// Version returns the current version and whether the last migration failed
+// halfway. A database without migrations has version 0.
+func (m *Migrator) Version() (uint, bool, error) {
+	version, dirty, err := m.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// Force sets the version without running migrations and clears the dirty
+// flag, used to recover after a failed migration was fixed by hand.
+func (m *Migrator) Force(version int) error {
+	return m.m.Force(version)
+}
+
+// Close releases the connection, leaving the parent *sql.DB open.
+func (m *Migrator) Close() error {
+	srcErr, dbErr := m.m.Close()
+	if srcErr != nil {
+		return srcErr
+	}
+	return dbErr
+}
+
+func ignoreNoChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
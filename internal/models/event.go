@@ -0,0 +1,38 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// StoredEvent is a service event as persisted in the event log.
+type StoredEvent struct {
+	ID         int64           `json:"id"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	TeamName   string          `json:"team_name,omitempty"`
+	UserIDs    []string        `json:"user_ids,omitempty"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// EventFilter selects events of a team and/or concerning a user. Empty
+// fields match everything.
+type EventFilter struct {
+	TeamName string
+	UserID   string
+}
+
+func (f EventFilter) Match(e *StoredEvent) bool {
+	if f.TeamName != "" && e.TeamName != f.TeamName {
+		return false
+	}
+	if f.UserID != "" {
+		for _, id := range e.UserIDs {
+			if id == f.UserID {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,25 @@
+package models
+
+type GitHubUser struct {
+	Login string `json:"login"`
+}
+
+type GitHubRepository struct {
+	FullName string `json:"full_name"`
+}
+
+type GitHubPullRequest struct {
+	Number int64      `json:"number"`
+	Title  string     `json:"title"`
+	State  string     `json:"state"`
+	Draft  bool       `json:"draft"`
+	Merged bool       `json:"merged"`
+	User   GitHubUser `json:"user"`
+}
+
+type GitHubPullRequestEvent struct {
+	Action      string            `json:"action"`
+	Number      int64             `json:"number"`
+	PullRequest GitHubPullRequest `json:"pull_request"`
+	Repository  GitHubRepository  `json:"repository"`
+}
@@ -0,0 +1,26 @@
+package models
+
+type GitLabUser struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+type GitLabProject struct {
+	ID                int64  `json:"id"`
+	PathWithNamespace string `json:"path_with_namespace"`
+}
+
+type GitLabMergeRequestAttributes struct {
+	IID      int64  `json:"iid"`
+	AuthorID int64  `json:"author_id"`
+	Title    string `json:"title"`
+	State    string `json:"state"`
+	Action   string `json:"action"`
+}
+
+type GitLabMergeRequestEvent struct {
+	ObjectKind       string                       `json:"object_kind"`
+	User             GitLabUser                   `json:"user"`
+	Project          GitLabProject                `json:"project"`
+	ObjectAttributes GitLabMergeRequestAttributes `json:"object_attributes"`
+}
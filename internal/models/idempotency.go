@@ -0,0 +1,12 @@
+package models
+
+type IdempotencyRecord struct {
+	Key         string
+	Method      string
+	Path        string
+	RequestHash string
+	StatusCode  int
+	ContentType string
+	Response    []byte
+	Completed   bool
+}
@@ -0,0 +1,36 @@
+package models
+
+const (
+	OrgChangeCreateTeam     = "create_team"
+	OrgChangeCreateUser     = "create_user"
+	OrgChangeUpdateUser     = "update_user"
+	OrgChangeMoveUser       = "move_user"
+	OrgChangeDeactivateUser = "deactivate_user"
+)
+
+// OrgChange is a single step needed to bring the database to the imported
+// state. User changes carry the full desired user, Details describes what
+// differs for updates.
+type OrgChange struct {
+	Action   string   `json:"action"`
+	TeamName string   `json:"team_name"`
+	UserID   string   `json:"user_id,omitempty"`
+	Username string   `json:"username,omitempty"`
+	IsActive bool     `json:"is_active"`
+	Details  []string `json:"details,omitempty"`
+}
+
+// OrgReassignment is an open review of a deactivated user that is (or, in a
+// dry run, would be) handed over to a teammate.
+type OrgReassignment struct {
+	PullRequestID string `json:"pull_request_id"`
+	OldUserID     string `json:"old_user_id"`
+	NewUserID     string `json:"new_user_id,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+type OrgDiff struct {
+	DryRun        bool              `json:"dry_run"`
+	Changes       []OrgChange       `json:"changes"`
+	Reassignments []OrgReassignment `json:"reassignments,omitempty"`
+}
@@ -20,9 +20,10 @@ type PullRequestShort struct {
 }
 
 type RequestPullRequestCreate struct {
-	PullRequestID   string `json:"pull_request_id"`
-	PullRequestName string `json:"pull_request_name"`
-	AuthorID        string `json:"author_id"`
+	PullRequestID   string   `json:"pull_request_id"`
+	PullRequestName string   `json:"pull_request_name"`
+	AuthorID        string   `json:"author_id"`
+	ChangedFiles    []string `json:"changed_files,omitempty"`
 }
 
 type RequestPullRequestMerge struct {
@@ -31,5 +32,78 @@ type RequestPullRequestMerge struct {
 
 type RequestPullRequestReassign struct {
 	PullRequestID string `json:"pull_request_id"`
-	OldUserID     string `json:"old_user_id"`
+	// OldUserID may be left empty when NewUserID is set; then the reviewer
+	// holding the review longest is replaced.
+	OldUserID string `json:"old_user_id,omitempty"`
+	// NewUserID picks the replacement instead of a random teammate.
+	NewUserID string `json:"new_user_id,omitempty"`
+}
+
+type RequestPullRequestReviewer struct {
+	PullRequestID string `json:"pull_request_id"`
+	UserID        string `json:"user_id"`
+}
+
+type RequestReviewDecline struct {
+	PullRequestID string `json:"pull_request_id"`
+	Reason        string `json:"reason"`
+}
+
+type RequestPullRequestTopUp struct {
+	PullRequestID string `json:"pull_request_id"`
+	Reviewers     int    `json:"reviewers"`
+}
+
+type StalePullRequest struct {
+	PullRequest
+	TeamName  string        `json:"team_name"`
+	Threshold time.Duration `json:"-"`
+}
+
+type ReviewAssignment struct {
+	PullRequestID string    `json:"pull_request_id"`
+	ReviewerID    string    `json:"reviewer_id"`
+	AssignedAt    time.Time `json:"assigned_at"`
+}
+
+// AssignmentExplanation records how reviewers were picked for a PR: who could
+// have been picked, who was left out and why, and how the pick was made.
+type AssignmentExplanation struct {
+	// Action is create, reassign, add, remove or top_up.
+	Action   string `json:"action"`
+	TeamName string `json:"team_name"`
+	// Pool is the replacement pool policy, on reassign, and Teams are the
+	// teams it drew candidates from.
+	Pool  string   `json:"pool,omitempty"`
+	Teams []string `json:"teams,omitempty"`
+	// ReplacedUserID is the reviewer being replaced, on reassign.
+	ReplacedUserID string `json:"replaced_user_id,omitempty"`
+	// RemovedUserID is the reviewer being removed, on remove.
+	RemovedUserID string `json:"removed_user_id,omitempty"`
+	// Candidates is the pool the reviewers were picked from.
+	Candidates []string            `json:"candidates"`
+	Excluded   []ExcludedCandidate `json:"excluded"`
+	// Preferred are the candidates owning the changed files, picked first.
+	Preferred []string `json:"preferred,omitempty"`
+	// Requested is how many reviewers were wanted.
+	Requested int `json:"requested"`
+	// Strategy is random, seeded or manual. A seeded pick is derived from the seed,
+	// Subject and the roster version, and can be derived again.
+	Strategy      string     `json:"strategy"`
+	Subject       []string   `json:"subject,omitempty"`
+	RosterVersion string     `json:"roster_version"`
+	Selected      []string   `json:"selected"`
+	CreatedAt     *time.Time `json:"created_at,omitempty"`
+}
+
+const (
+	ExcludedAuthor          = "AUTHOR"
+	ExcludedInactive        = "INACTIVE"
+	ExcludedAlreadyAssigned = "ALREADY_ASSIGNED"
+	ExcludedReplaced        = "REPLACED"
+)
+
+type ExcludedCandidate struct {
+	UserID string `json:"user_id"`
+	Reason string `json:"reason"`
 }
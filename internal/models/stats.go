@@ -0,0 +1,105 @@
+package models
+
+import "time"
+
+type PullRequestCounts struct {
+	Open   int `json:"open"`
+	Merged int `json:"merged"`
+	Closed int `json:"closed"`
+}
+
+type ReviewerLoad struct {
+	UserID       string `json:"user_id"`
+	Username     string `json:"username"`
+	TeamName     string `json:"team_name"`
+	IsActive     bool   `json:"is_active"`
+	OpenReviews  int    `json:"open_reviews"`
+	TotalReviews int    `json:"total_reviews"`
+}
+
+type ReviewStats struct {
+	PullRequests PullRequestCounts `json:"pull_requests"`
+	Reviewers    []ReviewerLoad    `json:"reviewers"`
+}
+
+// DurationStats summarizes durations in seconds.
+type DurationStats struct {
+	Count         int     `json:"count"`
+	MedianSeconds float64 `json:"median_seconds"`
+	P90Seconds    float64 `json:"p90_seconds"`
+}
+
+type TeamMergeStats struct {
+	TeamName string `json:"team_name"`
+	DurationStats
+}
+
+type AuthorMergeStats struct {
+	AuthorID string `json:"author_id"`
+	DurationStats
+}
+
+type ReviewerMergeStats struct {
+	ReviewerID string `json:"reviewer_id"`
+	DurationStats
+}
+
+// PullRequestStats covers PRs merged within [From, To). Teams and authors
+// report time from creation to merge, reviewers from assignment to merge.
+type PullRequestStats struct {
+	From      time.Time            `json:"from"`
+	To        time.Time            `json:"to"`
+	Teams     []TeamMergeStats     `json:"teams"`
+	Authors   []AuthorMergeStats   `json:"authors"`
+	Reviewers []ReviewerMergeStats `json:"reviewers"`
+}
+
+type ReviewAssignmentRecord struct {
+	PullRequestID string     `json:"pull_request_id"`
+	ReviewerID    string     `json:"reviewer_id"`
+	TeamName      string     `json:"team_name"`
+	AssignedAt    time.Time  `json:"assigned_at"`
+	EndedAt       *time.Time `json:"ended_at,omitempty"`
+	EndReason     string     `json:"end_reason,omitempty"`
+	// DeclineReason is what the reviewer said when declining the review.
+	DeclineReason string `json:"decline_reason,omitempty"`
+}
+
+// SLABreach is an assignment that was neither merged nor handed over before
+// its deadline. Open breaches have no EndedAt.
+type SLABreach struct {
+	ReviewAssignmentRecord
+	Deadline       time.Time `json:"deadline"`
+	OverdueSeconds float64   `json:"overdue_seconds"`
+}
+
+// SLACounts splits assignments into met, breached and pending (still open
+// and within the deadline).
+type SLACounts struct {
+	Assignments int `json:"assignments"`
+	Met         int `json:"met"`
+	Breached    int `json:"breached"`
+	Pending     int `json:"pending"`
+}
+
+type TeamSLAStats struct {
+	TeamName       string `json:"team_name"`
+	ReviewSLAHours int    `json:"review_sla_hours"`
+	SLACounts
+}
+
+type ReviewerSLAStats struct {
+	ReviewerID string `json:"reviewer_id"`
+	TeamName   string `json:"team_name"`
+	SLACounts
+}
+
+// ReviewSLAReport covers assignments made within [From, To). Reviewers are
+// ordered by the number of breaches, worst first.
+type ReviewSLAReport struct {
+	From      time.Time          `json:"from"`
+	To        time.Time          `json:"to"`
+	Teams     []TeamSLAStats     `json:"teams"`
+	Reviewers []ReviewerSLAStats `json:"reviewers"`
+	Breaches  []SLABreach        `json:"breaches"`
+}
@@ -6,12 +6,42 @@ type Teams struct {
 }
 
 type TeamMember struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	IsActive bool   `json:"is_active"`
+	UserID   string `json:"user_id" yaml:"user_id"`
+	Username string `json:"username" yaml:"username"`
+	IsActive bool   `json:"is_active" yaml:"is_active"`
 }
 
 type RequestTeamAdd struct {
-	TeamName string       `json:"team_name"`
-	Members  []TeamMember `json:"members"`
+	TeamName string       `json:"team_name" yaml:"team_name"`
+	Members  []TeamMember `json:"members" yaml:"members"`
+}
+
+type CodeOwnerRule struct {
+	Pattern string   `json:"pattern"`
+	Owners  []string `json:"owners"`
+}
+
+type RequestTeamSetCodeOwners struct {
+	TeamName string          `json:"team_name"`
+	Rules    []CodeOwnerRule `json:"rules"`
+}
+
+// TeamSettings holds per-team overrides; nil fields fall back to the
+// service-wide defaults.
+type TeamSettings struct {
+	TeamName              string `json:"team_name"`
+	StalePRThresholdHours *int   `json:"stale_pr_threshold_hours"`
+
+	// ReviewSLAHours is counted in working hours only. WorkDays are ISO
+	// weekdays (1 is Monday), WorkStartHour and WorkEndHour are local to
+	// Timezone.
+	ReviewSLAHours *int    `json:"review_sla_hours"`
+	WorkDays       []int   `json:"work_days"`
+	WorkStartHour  *int    `json:"work_start_hour"`
+	WorkEndHour    *int    `json:"work_end_hour"`
+	Timezone       *string `json:"timezone"`
+
+	// MonthlyDeclineQuota is how many reviews each member may decline per
+	// calendar month in Timezone.
+	MonthlyDeclineQuota *int `json:"monthly_decline_quota"`
 }
@@ -11,3 +11,9 @@ type RequestSetIsActive struct {
 	UserID   string `json:"user_id"`
 	IsActive bool   `json:"is_active"`
 }
+
+type ExternalAccount struct {
+	UserID   string `json:"user_id"`
+	Provider string `json:"provider"`
+	Login    string `json:"login"`
+}
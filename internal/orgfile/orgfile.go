@@ -0,0 +1,190 @@
+// Package orgfile reads and writes teams with their members in YAML, JSON
+// or CSV.
+//
+// YAML and JSON files hold a "teams" list of objects shaped like the
+// /team/add request. CSV files have a team_name,user_id,username,is_active
+// header and one row per member; a row with an empty user_id declares a team
+// without members.
+package orgfile
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+)
+
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+var csvHeader = []string{"team_name", "user_id", "username", "is_active"}
+
+var ErrUnknownFormat = errors.New("unknown org file format")
+
+type File struct {
+	Teams []models.RequestTeamAdd `json:"teams" yaml:"teams"`
+}
+
+// FormatFromPath guesses the format from the file extension.
+func FormatFromPath(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	case ".json":
+		return FormatJSON, nil
+	case ".csv":
+		return FormatCSV, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownFormat, path)
+}
+
+func Read(r io.Reader, format string) ([]models.RequestTeamAdd, error) {
+	var f File
+
+	switch format {
+	case FormatYAML:
+		if err := yaml.NewDecoder(r).Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+	case FormatJSON:
+		if err := json.NewDecoder(r).Decode(&f); err != nil {
+			return nil, err
+		}
+	case FormatCSV:
+		teams, err := readCSV(r)
+		if err != nil {
+			return nil, err
+		}
+		f.Teams = teams
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+
+	for i := range f.Teams {
+		if f.Teams[i].Members == nil {
+			f.Teams[i].Members = []models.TeamMember{}
+		}
+	}
+
+	return f.Teams, nil
+}
+
+func Write(w io.Writer, format string, teams []models.RequestTeamAdd) error {
+	switch format {
+	case FormatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(File{Teams: teams}); err != nil {
+			return err
+		}
+		return enc.Close()
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(File{Teams: teams})
+	case FormatCSV:
+		return writeCSV(w, teams)
+	}
+	return fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+}
+
+func readCSV(r io.Reader) ([]models.RequestTeamAdd, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = len(csvHeader)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return []models.RequestTeamAdd{}, nil
+		}
+		return nil, err
+	}
+	for i, name := range csvHeader {
+		if strings.TrimSpace(header[i]) != name {
+			return nil, fmt.Errorf("csv header must be %s", strings.Join(csvHeader, ","))
+		}
+	}
+
+	teams := make([]models.RequestTeamAdd, 0)
+	index := make(map[string]int)
+
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		teamName := record[0]
+		if teamName == "" {
+			line, _ := cr.FieldPos(0)
+			return nil, fmt.Errorf("line %d: team_name is required", line)
+		}
+
+		i, ok := index[teamName]
+		if !ok {
+			i = len(teams)
+			index[teamName] = i
+			teams = append(teams, models.RequestTeamAdd{
+				TeamName: teamName,
+				Members:  []models.TeamMember{},
+			})
+		}
+
+		if record[1] == "" {
+			continue
+		}
+
+		isActive, err := strconv.ParseBool(record[3])
+		if err != nil {
+			line, _ := cr.FieldPos(3)
+			return nil, fmt.Errorf("line %d: invalid is_active %q", line, record[3])
+		}
+
+		teams[i].Members = append(teams[i].Members, models.TeamMember{
+			UserID:   record[1],
+			Username: record[2],
+			IsActive: isActive,
+		})
+	}
+
+	return teams, nil
+}
+
+func writeCSV(w io.Writer, teams []models.RequestTeamAdd) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, team := range teams {
+		if len(team.Members) == 0 {
+			if err := cw.Write([]string{team.TeamName, "", "", ""}); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, m := range team.Members {
+			if err := cw.Write([]string{team.TeamName, m.UserID, m.Username, strconv.FormatBool(m.IsActive)}); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,51 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Wucop228/avito-PullRequest/internal/repo"
+)
+
+// PostgresStore keeps the buckets in the rate_limit_buckets table, shared by
+// all replicas. Like MemoryStore, it deletes buckets that have refilled once
+// per sweepInterval, so the table stays small without the scheduler.
+type PostgresStore struct {
+	db *sql.DB
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db, lastSweep: time.Now()}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, burst, rate float64) (float64, bool, error) {
+	s.sweep(ctx)
+	return repo.TakeRateLimitToken(ctx, s.db, key, burst, rate)
+}
+
+// sweep deletes refilled buckets if sweepInterval has passed since the last
+// sweep of this replica. A failed sweep is only logged: it is retried on the
+// next interval and must not hold up the request.
+func (s *PostgresStore) sweep(ctx context.Context) {
+	now := time.Now()
+
+	s.mu.Lock()
+	due := now.Sub(s.lastSweep) >= sweepInterval
+	if due {
+		s.lastSweep = now
+	}
+	s.mu.Unlock()
+
+	if !due {
+		return
+	}
+	if _, err := repo.DeleteFullRateLimitBuckets(ctx, s.db); err != nil {
+		slog.WarnContext(ctx, "rate limit buckets sweep failed", "error", err)
+	}
+}
@@ -0,0 +1,102 @@
+// Package ratelimit limits how often a client may call the API, with a token
+// bucket per client and route.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit allows Requests per Per on average, and up to Burst at once. Zero
+// Requests is unlimited; zero Burst equals Requests.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+	Burst    int
+}
+
+// rate is how many tokens the bucket earns per second.
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// Store keeps the buckets. Take takes a token from the bucket of key, which
+// holds up to burst tokens and earns rate tokens per second, and reports
+// whether it did and how many tokens are left.
+type Store interface {
+	Take(ctx context.Context, key string, burst, rate float64) (tokens float64, taken bool, err error)
+}
+
+// Result is the outcome of Allow, with what the client needs to know to stay
+// within the limit.
+type Result struct {
+	Allowed bool
+	// Limit is the limit applied, with Burst filled in.
+	Limit Limit
+	// Remaining is how many requests may follow right away.
+	Remaining int
+	// RetryAfter is when the next request is allowed, zero when it already is.
+	RetryAfter time.Duration
+	// Reset is when the bucket is full again.
+	Reset time.Duration
+}
+
+type Limiter struct {
+	store  Store
+	def    Limit
+	routes map[string]Limit
+}
+
+// New returns a limiter that applies routes[route] to the routes listed there
+// and def to all the others. Requests to the other routes share one bucket
+// per client.
+func New(store Store, def Limit, routes map[string]Limit) *Limiter {
+	return &Limiter{store: store, def: def, routes: routes}
+}
+
+// Routes lists the routes that have a limit of their own.
+func (l *Limiter) Routes() []string {
+	routes := make([]string, 0, len(l.routes))
+	for route := range l.routes {
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+// Allow takes a token for a request of client to route. Unlimited routes
+// are always allowed and have a zero Limit in the result.
+func (l *Limiter) Allow(ctx context.Context, route, client string) (Result, error) {
+	limit, ok := l.routes[route]
+	if !ok {
+		limit, route = l.def, ""
+	}
+	if limit.Requests == 0 {
+		return Result{Allowed: true}, nil
+	}
+
+	if limit.Burst == 0 {
+		limit.Burst = limit.Requests
+	}
+
+	burst, rate := float64(limit.Burst), limit.rate()
+	tokens, taken, err := l.store.Take(ctx, route+"|"+client, burst, rate)
+	if err != nil {
+		return Result{}, err
+	}
+
+	res := Result{
+		Allowed:   taken,
+		Limit:     limit,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+		Reset:     seconds((burst - tokens) / rate),
+	}
+	if !taken {
+		res.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	return res, nil
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Max(0, s) * float64(time.Second))
+}
@@ -0,0 +1,81 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+)
+
+// insertAssignmentExplanation stores e in the transaction of the assignment
+// it explains. A nil e stores nothing.
+func insertAssignmentExplanation(ctx context.Context, tx *sql.Tx, prID string, e *models.AssignmentExplanation, at time.Time) error {
+	if e == nil {
+		return nil
+	}
+
+	stored := *e
+	stored.CreatedAt = nil
+	payload, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO assignment_explanations (pull_request_id, action, explanation, created_at) VALUES ($1, $2, $3, $4)`,
+		prID,
+		e.Action,
+		payload,
+		at,
+	)
+	if err != nil {
+		return err
+	}
+
+	e.CreatedAt = &at
+	return nil
+}
+
+// GetAssignmentExplanations returns the explanations of every assignment
+// made for the PR, oldest first.
+func GetAssignmentExplanations(ctx context.Context, db *sql.DB, prID string) ([]models.AssignmentExplanation, error) {
+	ctx, span := startSpan(ctx, "GetAssignmentExplanations")
+	defer span.End()
+
+	query := `
+		SELECT explanation, created_at
+		FROM assignment_explanations
+		WHERE pull_request_id = $1
+		ORDER BY id
+	`
+
+	rows, err := idempotent(db).QueryContext(ctx, query, prID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	explanations := make([]models.AssignmentExplanation, 0)
+	for rows.Next() {
+		var payload []byte
+		var createdAt time.Time
+		if err := rows.Scan(&payload, &createdAt); err != nil {
+			return nil, err
+		}
+
+		var e models.AssignmentExplanation
+		if err := json.Unmarshal(payload, &e); err != nil {
+			return nil, err
+		}
+		e.CreatedAt = &createdAt
+		explanations = append(explanations, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return explanations, nil
+}
@@ -0,0 +1,285 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+)
+
+// The functions below load data for many keys in one query. They back the
+// GraphQL loaders, which collect the keys of a whole level of the query.
+
+func GetTeamNames(ctx context.Context, db *sql.DB) ([]string, error) {
+	ctx, span := startSpan(ctx, "GetTeamNames")
+	defer span.End()
+
+	rows, err := idempotent(db).QueryContext(ctx, `SELECT name FROM teams ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+// GetExistingTeamNames returns which of names are teams.
+func GetExistingTeamNames(ctx context.Context, db *sql.DB, names []string) ([]string, error) {
+	ctx, span := startSpan(ctx, "GetExistingTeamNames")
+	defer span.End()
+
+	rows, err := idempotent(db).QueryContext(ctx, `SELECT name FROM teams WHERE name = ANY($1)`, pq.Array(names))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := make([]string, 0, len(names))
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		found = append(found, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+func GetUsersByIDs(ctx context.Context, db *sql.DB, ids []string) ([]models.User, error) {
+	ctx, span := startSpan(ctx, "GetUsersByIDs")
+	defer span.End()
+
+	return queryUsers(ctx, db, `
+		SELECT id, username, team_name, is_active
+		FROM users
+		WHERE id = ANY($1)
+	`, pq.Array(ids))
+}
+
+func GetUsersByTeams(ctx context.Context, db *sql.DB, teamNames []string) ([]models.User, error) {
+	ctx, span := startSpan(ctx, "GetUsersByTeams")
+	defer span.End()
+
+	return queryUsers(ctx, db, `
+		SELECT id, username, team_name, is_active
+		FROM users
+		WHERE team_name = ANY($1)
+		ORDER BY team_name, id
+	`, pq.Array(teamNames))
+}
+
+func queryUsers(ctx context.Context, db *sql.DB, query string, args ...interface{}) ([]models.User, error) {
+	rows, err := idempotent(db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]models.User, 0)
+	for rows.Next() {
+		var u models.User
+		if err := rows.Scan(&u.UserID, &u.Username, &u.TeamName, &u.IsActive); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// Pull requests returned by the functions below have no AssignedReviewers;
+// use GetReviewersByPullRequests for them.
+
+func GetPullRequestsByIDs(ctx context.Context, db *sql.DB, ids []string) ([]models.PullRequest, error) {
+	ctx, span := startSpan(ctx, "GetPullRequestsByIDs")
+	defer span.End()
+
+	prs, _, err := queryPullRequests(ctx, db, `
+		SELECT id, name, author_id, status, created_at, merged_at, ''
+		FROM pull_requests
+		WHERE id = ANY($1)
+	`, pq.Array(ids))
+	return prs, err
+}
+
+// ListPullRequests returns the newest PRs first. Empty status or authorID
+// match everything.
+func ListPullRequests(ctx context.Context, db *sql.DB, status, authorID string, limit int) ([]models.PullRequest, error) {
+	ctx, span := startSpan(ctx, "ListPullRequests")
+	defer span.End()
+
+	prs, _, err := queryPullRequests(ctx, db, `
+		SELECT id, name, author_id, status, created_at, merged_at, ''
+		FROM pull_requests
+		WHERE ($1 = '' OR status = $1) AND ($2 = '' OR author_id = $2)
+		ORDER BY created_at DESC, id
+		LIMIT $3
+	`, status, authorID, limit)
+	return prs, err
+}
+
+func GetPullRequestsByAuthors(ctx context.Context, db *sql.DB, authorIDs []string) (map[string][]models.PullRequest, error) {
+	ctx, span := startSpan(ctx, "GetPullRequestsByAuthors")
+	defer span.End()
+
+	prs, _, err := queryPullRequests(ctx, db, `
+		SELECT id, name, author_id, status, created_at, merged_at, ''
+		FROM pull_requests
+		WHERE author_id = ANY($1)
+		ORDER BY created_at, id
+	`, pq.Array(authorIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	byAuthor := make(map[string][]models.PullRequest)
+	for _, pr := range prs {
+		byAuthor[pr.AuthorID] = append(byAuthor[pr.AuthorID], pr)
+	}
+	return byAuthor, nil
+}
+
+func GetPullRequestsByReviewers(ctx context.Context, db *sql.DB, reviewerIDs []string) (map[string][]models.PullRequest, error) {
+	ctx, span := startSpan(ctx, "GetPullRequestsByReviewers")
+	defer span.End()
+
+	prs, reviewers, err := queryPullRequests(ctx, db, `
+		SELECT pr.id, pr.name, pr.author_id, pr.status, pr.created_at, pr.merged_at, r.reviewer_id
+		FROM pull_requests pr
+		JOIN pull_request_reviewers r ON r.pull_request_id = pr.id
+		WHERE r.reviewer_id = ANY($1)
+		ORDER BY pr.created_at, pr.id
+	`, pq.Array(reviewerIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	byReviewer := make(map[string][]models.PullRequest)
+	for i, pr := range prs {
+		byReviewer[reviewers[i]] = append(byReviewer[reviewers[i]], pr)
+	}
+	return byReviewer, nil
+}
+
+// queryPullRequests scans PR rows followed by a grouping key column, which
+// is returned in the second slice.
+func queryPullRequests(ctx context.Context, db *sql.DB, query string, args ...interface{}) ([]models.PullRequest, []string, error) {
+	rows, err := idempotent(db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	prs := make([]models.PullRequest, 0)
+	keys := make([]string, 0)
+	for rows.Next() {
+		var pr models.PullRequest
+		var createdAt time.Time
+		var mergedAt sql.NullTime
+		var key string
+		if err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &createdAt, &mergedAt, &key); err != nil {
+			return nil, nil, err
+		}
+		pr.CreatedAt = &createdAt
+		if mergedAt.Valid {
+			m := mergedAt.Time
+			pr.MergedAt = &m
+		}
+		prs = append(prs, pr)
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return prs, keys, nil
+}
+
+// GetReviewersByPullRequests returns the current reviewers of each PR.
+func GetReviewersByPullRequests(ctx context.Context, db *sql.DB, prIDs []string) (map[string][]models.User, error) {
+	ctx, span := startSpan(ctx, "GetReviewersByPullRequests")
+	defer span.End()
+
+	rows, err := idempotent(db).QueryContext(ctx, `
+		SELECT r.pull_request_id, u.id, u.username, u.team_name, u.is_active
+		FROM pull_request_reviewers r
+		JOIN users u ON u.id = r.reviewer_id
+		WHERE r.pull_request_id = ANY($1)
+		ORDER BY r.pull_request_id, u.id
+	`, pq.Array(prIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byPR := make(map[string][]models.User)
+	for rows.Next() {
+		var prID string
+		var u models.User
+		if err := rows.Scan(&prID, &u.UserID, &u.Username, &u.TeamName, &u.IsActive); err != nil {
+			return nil, err
+		}
+		byPR[prID] = append(byPR[prID], u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return byPR, nil
+}
+
+// GetReviewAssignmentsByPullRequests returns the assignment history of each
+// PR, oldest first.
+func GetReviewAssignmentsByPullRequests(ctx context.Context, db *sql.DB, prIDs []string) (map[string][]models.ReviewAssignmentRecord, error) {
+	ctx, span := startSpan(ctx, "GetReviewAssignmentsByPullRequests")
+	defer span.End()
+
+	rows, err := idempotent(db).QueryContext(ctx, `
+		SELECT ra.pull_request_id, ra.reviewer_id, u.team_name, ra.assigned_at, ra.ended_at, ra.end_reason, ra.decline_reason
+		FROM review_assignments ra
+		JOIN pull_requests pr ON pr.id = ra.pull_request_id
+		JOIN users u ON u.id = pr.author_id
+		WHERE ra.pull_request_id = ANY($1)
+		ORDER BY ra.assigned_at, ra.id
+	`, pq.Array(prIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byPR := make(map[string][]models.ReviewAssignmentRecord)
+	for rows.Next() {
+		r, err := scanReviewAssignment(rows)
+		if err != nil {
+			return nil, err
+		}
+		byPR[r.PullRequestID] = append(byPR[r.PullRequestID], r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return byPR, nil
+}
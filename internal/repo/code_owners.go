@@ -0,0 +1,66 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+)
+
+func ReplaceTeamCodeOwners(ctx context.Context, db *sql.DB, teamName string, rules []models.CodeOwnerRule) error {
+	ctx, span := startSpan(ctx, "ReplaceTeamCodeOwners")
+	defer span.End()
+
+	return inTx(ctx, db, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM team_code_owners WHERE team_name = $1`, teamName); err != nil {
+			return err
+		}
+
+		stmt, err := tx.PrepareContext(ctx, `
+			INSERT INTO team_code_owners (team_name, position, pattern, owners)
+			VALUES ($1, $2, $3, $4)
+		`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for i, rule := range rules {
+			if _, err := stmt.ExecContext(ctx, teamName, i, rule.Pattern, pq.Array(rule.Owners)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetTeamCodeOwners returns the team's rules in the order they were defined.
+func GetTeamCodeOwners(ctx context.Context, db *sql.DB, teamName string) ([]models.CodeOwnerRule, error) {
+	ctx, span := startSpan(ctx, "GetTeamCodeOwners")
+	defer span.End()
+
+	query := "SELECT pattern, owners FROM team_code_owners WHERE team_name = $1 ORDER BY position"
+
+	rows, err := idempotent(db).QueryContext(ctx, query, teamName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := make([]models.CodeOwnerRule, 0)
+	for rows.Next() {
+		var rule models.CodeOwnerRule
+		if err := rows.Scan(&rule.Pattern, pq.Array(&rule.Owners)); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
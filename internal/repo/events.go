@@ -0,0 +1,98 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+)
+
+func InsertEvent(ctx context.Context, db *sql.DB, e *models.StoredEvent) error {
+	ctx, span := startSpan(ctx, "InsertEvent")
+	defer span.End()
+
+	query := `
+		INSERT INTO events (type, team_name, user_ids, payload, occurred_at)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5)
+		RETURNING id
+	`
+
+	userIDs := e.UserIDs
+	if userIDs == nil {
+		userIDs = []string{}
+	}
+
+	// Not retried: a lost connection may leave the event stored, and a
+	// retry would store it twice.
+	return db.QueryRowContext(
+		ctx,
+		query,
+		e.Type,
+		e.TeamName,
+		pq.Array(userIDs),
+		[]byte(e.Payload),
+		e.OccurredAt,
+	).Scan(&e.ID)
+}
+
+// GetEventsAfter returns up to limit events with id greater than afterID
+// that match the filter, oldest first.
+func GetEventsAfter(ctx context.Context, db *sql.DB, afterID int64, filter models.EventFilter, limit int) ([]models.StoredEvent, error) {
+	ctx, span := startSpan(ctx, "GetEventsAfter")
+	defer span.End()
+
+	query := `
+		SELECT id, type, COALESCE(team_name, ''), user_ids, payload, occurred_at
+		FROM events
+		WHERE id > $1
+			AND ($2 = '' OR team_name = $2)
+			AND ($3 = '' OR $3 = ANY(user_ids))
+		ORDER BY id
+		LIMIT $4
+	`
+
+	rows, err := idempotent(db).QueryContext(ctx, query, afterID, filter.TeamName, filter.UserID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]models.StoredEvent, 0)
+	for rows.Next() {
+		var e models.StoredEvent
+		var payload []byte
+		if err := rows.Scan(&e.ID, &e.Type, &e.TeamName, pq.Array(&e.UserIDs), &payload, &e.OccurredAt); err != nil {
+			return nil, err
+		}
+		e.Payload = payload
+		list = append(list, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func GetLastEventID(ctx context.Context, db *sql.DB) (int64, error) {
+	ctx, span := startSpan(ctx, "GetLastEventID")
+	defer span.End()
+
+	var id int64
+	err := idempotent(db).QueryRowContext(ctx, `SELECT COALESCE(MAX(id), 0) FROM events`).Scan(&id)
+	return id, err
+}
+
+func DeleteEventsBefore(ctx context.Context, db *sql.DB, before time.Time) (int64, error) {
+	ctx, span := startSpan(ctx, "DeleteEventsBefore")
+	defer span.End()
+
+	res, err := idempotent(db).ExecContext(ctx, `DELETE FROM events WHERE occurred_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
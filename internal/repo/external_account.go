@@ -0,0 +1,42 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+)
+
+func UpsertExternalAccount(ctx context.Context, db *sql.DB, account *models.ExternalAccount) error {
+	ctx, span := startSpan(ctx, "UpsertExternalAccount")
+	defer span.End()
+
+	query := `
+		INSERT INTO external_accounts (provider, login, user_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (provider, login) DO UPDATE
+		SET user_id = EXCLUDED.user_id
+	`
+
+	_, err := idempotent(db).ExecContext(ctx, query, account.Provider, account.Login, account.UserID)
+	return err
+}
+
+func GetUserIDByExternalLogin(ctx context.Context, db *sql.DB, provider, login string) (string, error) {
+	ctx, span := startSpan(ctx, "GetUserIDByExternalLogin")
+	defer span.End()
+
+	query := "SELECT user_id FROM external_accounts WHERE provider = $1 AND login = $2"
+
+	var userID string
+	err := idempotent(db).QueryRowContext(ctx, query, provider, login).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return userID, nil
+}
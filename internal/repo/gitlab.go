@@ -0,0 +1,39 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+func GetPullRequestIDByGitLabMergeRequest(ctx context.Context, db *sql.DB, projectID, iid int64) (string, error) {
+	ctx, span := startSpan(ctx, "GetPullRequestIDByGitLabMergeRequest")
+	defer span.End()
+
+	query := "SELECT pull_request_id FROM gitlab_merge_requests WHERE project_id = $1 AND mr_iid = $2"
+
+	var prID string
+	err := idempotent(db).QueryRowContext(ctx, query, projectID, iid).Scan(&prID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return prID, nil
+}
+
+func LinkGitLabMergeRequest(ctx context.Context, db *sql.DB, projectID, iid int64, prID string) error {
+	ctx, span := startSpan(ctx, "LinkGitLabMergeRequest")
+	defer span.End()
+
+	query := `
+		INSERT INTO gitlab_merge_requests (project_id, mr_iid, pull_request_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (project_id, mr_iid) DO NOTHING
+	`
+
+	_, err := idempotent(db).ExecContext(ctx, query, projectID, iid, prID)
+	return err
+}
@@ -0,0 +1,106 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+)
+
+// ReserveIdempotencyKey stores a pending record for the key and reports
+// whether it was inserted. Expired records are purged first so that their
+// keys can be reused.
+func ReserveIdempotencyKey(ctx context.Context, db *sql.DB, rec *models.IdempotencyRecord, ttl time.Duration) (bool, error) {
+	ctx, span := startSpan(ctx, "ReserveIdempotencyKey")
+	defer span.End()
+
+	if _, err := idempotent(db).ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at < NOW()`); err != nil {
+		return false, err
+	}
+
+	query := `
+		INSERT INTO idempotency_keys (key, method, path, request_hash, expires_at)
+		VALUES ($1, $2, $3, $4, NOW() + make_interval(secs => $5))
+		ON CONFLICT (key, method, path) DO NOTHING
+	`
+
+	// Not retried: after a lost connection the key may be reserved by this
+	// very call, and a retry would report it as taken.
+	res, err := db.ExecContext(ctx, query, rec.Key, rec.Method, rec.Path, rec.RequestHash, ttl.Seconds())
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n == 1, nil
+}
+
+func GetIdempotencyRecord(ctx context.Context, db *sql.DB, key, method, path string) (*models.IdempotencyRecord, error) {
+	ctx, span := startSpan(ctx, "GetIdempotencyRecord")
+	defer span.End()
+
+	query := `
+		SELECT request_hash, status_code, content_type, response
+		FROM idempotency_keys
+		WHERE key = $1 AND method = $2 AND path = $3
+	`
+
+	rec := &models.IdempotencyRecord{Key: key, Method: method, Path: path}
+	var statusCode sql.NullInt64
+	var contentType sql.NullString
+
+	err := idempotent(db).QueryRowContext(ctx, query, key, method, path).Scan(
+		&rec.RequestHash,
+		&statusCode,
+		&contentType,
+		&rec.Response,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if statusCode.Valid {
+		rec.StatusCode = int(statusCode.Int64)
+		rec.ContentType = contentType.String
+		rec.Completed = true
+	}
+
+	return rec, nil
+}
+
+func CompleteIdempotencyKey(ctx context.Context, db *sql.DB, rec *models.IdempotencyRecord) error {
+	ctx, span := startSpan(ctx, "CompleteIdempotencyKey")
+	defer span.End()
+
+	query := `
+		UPDATE idempotency_keys
+		SET status_code = $4, content_type = $5, response = $6
+		WHERE key = $1 AND method = $2 AND path = $3
+	`
+
+	_, err := idempotent(db).ExecContext(ctx, query, rec.Key, rec.Method, rec.Path, rec.StatusCode, rec.ContentType, rec.Response)
+	return err
+}
+
+func DeleteIdempotencyKey(ctx context.Context, db *sql.DB, key, method, path string) error {
+	ctx, span := startSpan(ctx, "DeleteIdempotencyKey")
+	defer span.End()
+
+	_, err := idempotent(db).ExecContext(
+		ctx,
+		`DELETE FROM idempotency_keys WHERE key = $1 AND method = $2 AND path = $3`,
+		key,
+		method,
+		path,
+	)
+	return err
+}
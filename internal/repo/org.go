@@ -0,0 +1,96 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+)
+
+// GetAllTeamsWithMembers returns every team, including teams without
+// members, ordered by team name and user id.
+func GetAllTeamsWithMembers(ctx context.Context, db *sql.DB) ([]models.RequestTeamAdd, error) {
+	ctx, span := startSpan(ctx, "GetAllTeamsWithMembers")
+	defer span.End()
+
+	query := `
+		SELECT t.name, u.id, u.username, u.is_active
+		FROM teams t
+		LEFT JOIN users u ON u.team_name = t.name
+		ORDER BY t.name, u.id
+	`
+
+	rows, err := idempotent(db).QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams := make([]models.RequestTeamAdd, 0)
+	for rows.Next() {
+		var teamName string
+		var userID, username sql.NullString
+		var isActive sql.NullBool
+		if err := rows.Scan(&teamName, &userID, &username, &isActive); err != nil {
+			return nil, err
+		}
+
+		if len(teams) == 0 || teams[len(teams)-1].TeamName != teamName {
+			teams = append(teams, models.RequestTeamAdd{
+				TeamName: teamName,
+				Members:  []models.TeamMember{},
+			})
+		}
+		if !userID.Valid {
+			continue
+		}
+
+		last := &teams[len(teams)-1]
+		last.Members = append(last.Members, models.TeamMember{
+			UserID:   userID.String,
+			Username: username.String,
+			IsActive: isActive.Bool,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return teams, nil
+}
+
+// ApplyOrgChanges applies all changes in a single transaction.
+func ApplyOrgChanges(ctx context.Context, db *sql.DB, changes []models.OrgChange) error {
+	ctx, span := startSpan(ctx, "ApplyOrgChanges")
+	defer span.End()
+
+	return inTx(ctx, db, func(tx *sql.Tx) error {
+		upsertUser := `
+			INSERT INTO users (id, username, team_name, is_active)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (id) DO UPDATE
+			SET username = EXCLUDED.username,
+				team_name = EXCLUDED.team_name,
+				is_active = EXCLUDED.is_active
+		`
+
+		for _, change := range changes {
+			switch change.Action {
+			case models.OrgChangeCreateTeam:
+				if _, err := tx.ExecContext(ctx, `INSERT INTO teams (name) VALUES ($1)`, change.TeamName); err != nil {
+					return err
+				}
+			case models.OrgChangeCreateUser, models.OrgChangeUpdateUser, models.OrgChangeMoveUser:
+				if _, err := tx.ExecContext(ctx, upsertUser, change.UserID, change.Username, change.TeamName, change.IsActive); err != nil {
+					return err
+				}
+			case models.OrgChangeDeactivateUser:
+				if _, err := tx.ExecContext(ctx, `UPDATE users SET is_active = FALSE WHERE id = $1`, change.UserID); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
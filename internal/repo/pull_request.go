@@ -1,6 +1,7 @@
 package repo
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"time"
@@ -8,7 +9,18 @@ import (
 	"github.com/Wucop228/avito-PullRequest/internal/models"
 )
 
-func GetPullRequestWithReviewers(db *sql.DB, id string) (*models.PullRequest, error) {
+// Errors of reviewer changes whose preconditions, checked by the service
+// beforehand, no longer hold by the time the transaction runs.
+var (
+	ErrPullRequestNotOpen  = errors.New("pull request is not open")
+	ErrPullRequestMerged   = errors.New("cannot reassign on merged PR")
+	ErrReviewerNotAssigned = errors.New("reviewer is not assigned to this PR")
+)
+
+func GetPullRequestWithReviewers(ctx context.Context, db *sql.DB, id string) (*models.PullRequest, error) {
+	ctx, span := startSpan(ctx, "GetPullRequestWithReviewers")
+	defer span.End()
+
 	query := `
 		SELECT id, name, author_id, status, created_at, merged_at
 		FROM pull_requests
@@ -19,7 +31,7 @@ func GetPullRequestWithReviewers(db *sql.DB, id string) (*models.PullRequest, er
 	var createdAt time.Time
 	var mergedAt sql.NullTime
 
-	err := db.QueryRow(query, id).Scan(
+	err := idempotent(db).QueryRowContext(ctx, query, id).Scan(
 		&pr.PullRequestID,
 		&pr.PullRequestName,
 		&pr.AuthorID,
@@ -40,7 +52,8 @@ func GetPullRequestWithReviewers(db *sql.DB, id string) (*models.PullRequest, er
 		pr.MergedAt = &m
 	}
 
-	rows, err := db.Query(
+	rows, err := idempotent(db).QueryContext(
+		ctx,
 		`SELECT reviewer_id FROM pull_request_reviewers WHERE pull_request_id = $1`,
 		id,
 	)
@@ -66,43 +79,49 @@ func GetPullRequestWithReviewers(db *sql.DB, id string) (*models.PullRequest, er
 	return &pr, nil
 }
 
-func CreatePullRequest(db *sql.DB, req *models.RequestPullRequestCreate, reviewerIDs []string) (*models.PullRequest, error) {
-	tx, err := db.Begin()
-	if err != nil {
-		return nil, err
-	}
-	defer tx.Rollback()
+// CreatePullRequest stores the PR with its reviewers and, when it is not nil,
+// the explanation of how they were picked.
+func CreatePullRequest(ctx context.Context, db *sql.DB, req *models.RequestPullRequestCreate, reviewerIDs []string, explanation *models.AssignmentExplanation) (*models.PullRequest, error) {
+	ctx, span := startSpan(ctx, "CreatePullRequest")
+	defer span.End()
 
 	var createdAt time.Time
-	insertPR := `
-		INSERT INTO pull_requests (id, name, author_id, status)
-		VALUES ($1, $2, $3, $4)
-		RETURNING created_at
-	`
-	err = tx.QueryRow(
-		insertPR,
-		req.PullRequestID,
-		req.PullRequestName,
-		req.AuthorID,
-		"OPEN",
-	).Scan(&createdAt)
-	if err != nil {
-		return nil, err
-	}
-
-	if len(reviewerIDs) > 0 {
-		insertReviewer := `
-			INSERT INTO pull_request_reviewers (pull_request_id, reviewer_id)
-			VALUES ($1, $2)
+	err := inTx(ctx, db, func(tx *sql.Tx) error {
+		insertPR := `
+			INSERT INTO pull_requests (id, name, author_id, status)
+			VALUES ($1, $2, $3, $4)
+			RETURNING created_at
 		`
-		for _, r := range reviewerIDs {
-			if _, err := tx.Exec(insertReviewer, req.PullRequestID, r); err != nil {
-				return nil, err
+		err := tx.QueryRowContext(
+			ctx,
+			insertPR,
+			req.PullRequestID,
+			req.PullRequestName,
+			req.AuthorID,
+			"OPEN",
+		).Scan(&createdAt)
+		if err != nil {
+			return err
+		}
+
+		if len(reviewerIDs) > 0 {
+			insertReviewer := `
+				INSERT INTO pull_request_reviewers (pull_request_id, reviewer_id, assigned_at)
+				VALUES ($1, $2, $3)
+			`
+			for _, r := range reviewerIDs {
+				if _, err := tx.ExecContext(ctx, insertReviewer, req.PullRequestID, r, createdAt); err != nil {
+					return err
+				}
+				if err := startReviewAssignment(ctx, tx, req.PullRequestID, r, createdAt); err != nil {
+					return err
+				}
 			}
 		}
-	}
 
-	if err := tx.Commit(); err != nil {
+		return insertAssignmentExplanation(ctx, tx, req.PullRequestID, explanation, createdAt)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -118,7 +137,10 @@ func CreatePullRequest(db *sql.DB, req *models.RequestPullRequestCreate, reviewe
 	return pr, nil
 }
 
-func MarkPullRequestMerged(db *sql.DB, id string) (*time.Time, error) {
+func MarkPullRequestMerged(ctx context.Context, db *sql.DB, id string) (*time.Time, error) {
+	ctx, span := startSpan(ctx, "MarkPullRequestMerged")
+	defer span.End()
+
 	query := `
 		UPDATE pull_requests
 		SET status = 'MERGED', merged_at = NOW()
@@ -127,40 +149,208 @@ func MarkPullRequestMerged(db *sql.DB, id string) (*time.Time, error) {
 	`
 
 	var mergedAt time.Time
-	if err := db.QueryRow(query, id).Scan(&mergedAt); err != nil {
+	err := inTx(ctx, db, func(tx *sql.Tx) error {
+		if err := tx.QueryRowContext(ctx, query, id).Scan(&mergedAt); err != nil {
+			return err
+		}
+
+		return endReviewAssignments(ctx, tx, id, "merged", mergedAt)
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return &mergedAt, nil
 }
 
-func ReplacePullRequestReviewer(db *sql.DB, prID, oldReviewerID, newReviewerID string) error {
-	tx, err := db.Begin()
+// ReplacePullRequestReviewer swaps the reviewer and, when it is not nil,
+// stores the explanation of how the new one was picked.
+func ReplacePullRequestReviewer(ctx context.Context, db *sql.DB, prID, oldReviewerID, newReviewerID string, explanation *models.AssignmentExplanation) error {
+	ctx, span := startSpan(ctx, "ReplacePullRequestReviewer")
+	defer span.End()
+
+	return inTx(ctx, db, func(tx *sql.Tx) error {
+		status, err := lockPullRequest(ctx, tx, prID)
+		if err != nil {
+			return err
+		}
+		switch status {
+		case "OPEN":
+		case "MERGED":
+			return ErrPullRequestMerged
+		default:
+			return ErrPullRequestNotOpen
+		}
+		return replaceReviewer(ctx, tx, prID, oldReviewerID, newReviewerID, "reassigned", "", explanation)
+	})
+}
+
+// DeclinePullRequestReview swaps a reviewer who declined the review and
+// records their reason, unless they already declined quota reviews since the
+// given time. The PR and the reviewer are locked meanwhile, so that the PR
+// cannot be closed underneath and concurrent declines cannot get past the
+// quota together. It returns how many reviews the
+// reviewer declined since then, this one included if it went through.
+func DeclinePullRequestReview(ctx context.Context, db *sql.DB, prID, reviewerID, newReviewerID, reason string, quota int, since time.Time, explanation *models.AssignmentExplanation) (int, bool, error) {
+	ctx, span := startSpan(ctx, "DeclinePullRequestReview")
+	defer span.End()
+
+	var declined int
+	var ok bool
+	err := inTx(ctx, db, func(tx *sql.Tx) error {
+		if err := lockOpenPullRequest(ctx, tx, prID); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `SELECT 1 FROM users WHERE id = $1 FOR UPDATE`, reviewerID); err != nil {
+			return err
+		}
+
+		if err := tx.QueryRowContext(ctx, countDeclinedReviewsQuery, reviewerID, since).Scan(&declined); err != nil {
+			return err
+		}
+		if ok = declined < quota; !ok {
+			return nil
+		}
+		declined++
+
+		return replaceReviewer(ctx, tx, prID, reviewerID, newReviewerID, "declined", reason, explanation)
+	})
+	if err != nil {
+		return 0, false, err
+	}
+
+	return declined, ok, nil
+}
+
+const countDeclinedReviewsQuery = `
+	SELECT COUNT(*) FROM review_assignments
+	WHERE reviewer_id = $1 AND end_reason = 'declined' AND ended_at >= $2
+`
+
+// CountDeclinedReviews returns how many reviews the user declined since the
+// given time.
+func CountDeclinedReviews(ctx context.Context, db *sql.DB, userID string, since time.Time) (int, error) {
+	ctx, span := startSpan(ctx, "CountDeclinedReviews")
+	defer span.End()
+
+	var n int
+	if err := idempotent(db).QueryRowContext(ctx, countDeclinedReviewsQuery, userID, since).Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// lockPullRequest locks the PR row until the end of tx, so that its status
+// cannot change meanwhile, and returns the status. It is empty when there is
+// no such PR.
+func lockPullRequest(ctx context.Context, tx *sql.Tx, prID string) (string, error) {
+	var status string
+	err := tx.QueryRowContext(ctx, `SELECT status FROM pull_requests WHERE id = $1 FOR UPDATE`, prID).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return status, err
+}
+
+// lockOpenPullRequest locks the PR like lockPullRequest and fails with
+// ErrPullRequestNotOpen unless it is open.
+func lockOpenPullRequest(ctx context.Context, tx *sql.Tx, prID string) error {
+	status, err := lockPullRequest(ctx, tx, prID)
 	if err != nil {
 		return err
 	}
-	defer tx.Rollback()
+	if status != "OPEN" {
+		return ErrPullRequestNotOpen
+	}
+	return nil
+}
 
-	if _, err := tx.Exec(
+// replaceReviewer ends the assignment of the old reviewer for endReason,
+// with declineReason when the reviewer declined, and assigns the new one.
+// It fails with ErrReviewerNotAssigned, rolling tx back, when the old
+// reviewer was unassigned concurrently.
+func replaceReviewer(ctx context.Context, tx *sql.Tx, prID, oldReviewerID, newReviewerID, endReason, declineReason string, explanation *models.AssignmentExplanation) error {
+	res, err := tx.ExecContext(
+		ctx,
 		`DELETE FROM pull_request_reviewers WHERE pull_request_id = $1 AND reviewer_id = $2`,
 		prID,
 		oldReviewerID,
-	); err != nil {
+	)
+	if err := expectOneRow(res, err); err != nil {
 		return err
 	}
 
-	if _, err := tx.Exec(
-		`INSERT INTO pull_request_reviewers (pull_request_id, reviewer_id) VALUES ($1, $2)`,
+	var assignedAt time.Time
+	if err := tx.QueryRowContext(
+		ctx,
+		`INSERT INTO pull_request_reviewers (pull_request_id, reviewer_id) VALUES ($1, $2) RETURNING assigned_at`,
 		prID,
 		newReviewerID,
-	); err != nil {
+	).Scan(&assignedAt); err != nil {
+		return err
+	}
+
+	res, err = tx.ExecContext(
+		ctx,
+		`UPDATE review_assignments SET ended_at = $3, end_reason = $4, decline_reason = NULLIF($5, '')
+		WHERE pull_request_id = $1 AND reviewer_id = $2 AND ended_at IS NULL`,
+		prID,
+		oldReviewerID,
+		assignedAt,
+		endReason,
+		declineReason,
+	)
+	if err := expectOneRow(res, err); err != nil {
+		return err
+	}
+
+	if err := startReviewAssignment(ctx, tx, prID, newReviewerID, assignedAt); err != nil {
 		return err
 	}
 
-	return tx.Commit()
+	return insertAssignmentExplanation(ctx, tx, prID, explanation, assignedAt)
+}
+
+// expectOneRow turns a statement on a removed reviewer that did not affect
+// exactly one row into ErrReviewerNotAssigned.
+func expectOneRow(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return ErrReviewerNotAssigned
+	}
+	return nil
+}
+
+// GetLongestAssignedReviewer returns the reviewer of the PR assigned
+// earliest, or "" when it has none.
+func GetLongestAssignedReviewer(ctx context.Context, db *sql.DB, prID string) (string, error) {
+	ctx, span := startSpan(ctx, "GetLongestAssignedReviewer")
+	defer span.End()
+
+	var reviewerID string
+	err := idempotent(db).QueryRowContext(
+		ctx,
+		`SELECT reviewer_id FROM pull_request_reviewers WHERE pull_request_id = $1
+		ORDER BY assigned_at, reviewer_id LIMIT 1`,
+		prID,
+	).Scan(&reviewerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return reviewerID, err
 }
 
-func GetPullRequestsByReviewer(db *sql.DB, userID string) ([]models.PullRequestShort, error) {
+func GetPullRequestsByReviewer(ctx context.Context, db *sql.DB, userID string) ([]models.PullRequestShort, error) {
+	ctx, span := startSpan(ctx, "GetPullRequestsByReviewer")
+	defer span.End()
+
 	query := `
 		SELECT pr.id, pr.name, pr.author_id, pr.status
 		FROM pull_requests pr
@@ -169,7 +359,7 @@ func GetPullRequestsByReviewer(db *sql.DB, userID string) ([]models.PullRequestS
 		ORDER BY pr.created_at
 	`
 
-	rows, err := db.Query(query, userID)
+	rows, err := idempotent(db).QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -189,3 +379,153 @@ func GetPullRequestsByReviewer(db *sql.DB, userID string) ([]models.PullRequestS
 
 	return prs, nil
 }
+
+// SetPullRequestStatus moves a PR between OPEN and CLOSED. Closing ends the
+// current review assignments; reopening starts them over.
+func SetPullRequestStatus(ctx context.Context, db *sql.DB, id, status string) error {
+	ctx, span := startSpan(ctx, "SetPullRequestStatus")
+	defer span.End()
+
+	return inTx(ctx, db, func(tx *sql.Tx) error {
+		var now time.Time
+		if err := tx.QueryRowContext(
+			ctx,
+			`UPDATE pull_requests SET status = $2 WHERE id = $1 RETURNING NOW()`,
+			id,
+			status,
+		).Scan(&now); err != nil {
+			return err
+		}
+
+		switch status {
+		case "CLOSED":
+			if err := endReviewAssignments(ctx, tx, id, "closed", now); err != nil {
+				return err
+			}
+		case "OPEN":
+			if _, err := tx.ExecContext(
+				ctx,
+				`UPDATE pull_request_reviewers SET assigned_at = $2 WHERE pull_request_id = $1`,
+				id,
+				now,
+			); err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(
+				ctx,
+				`INSERT INTO review_assignments (pull_request_id, reviewer_id, assigned_at)
+				SELECT pull_request_id, reviewer_id, assigned_at
+				FROM pull_request_reviewers
+				WHERE pull_request_id = $1`,
+				id,
+			); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func startReviewAssignment(ctx context.Context, tx *sql.Tx, prID, reviewerID string, at time.Time) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO review_assignments (pull_request_id, reviewer_id, assigned_at) VALUES ($1, $2, $3)`,
+		prID,
+		reviewerID,
+		at,
+	)
+	return err
+}
+
+func endReviewAssignments(ctx context.Context, tx *sql.Tx, prID, reason string, at time.Time) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`UPDATE review_assignments SET ended_at = $3, end_reason = $2
+		WHERE pull_request_id = $1 AND ended_at IS NULL`,
+		prID,
+		reason,
+		at,
+	)
+	return err
+}
+
+// AddPullRequestReviewers assigns more reviewers to the PR and, when it is
+// not nil, stores the explanation of how they were picked. It fails with
+// ErrPullRequestNotOpen when the PR was merged or closed concurrently.
+func AddPullRequestReviewers(ctx context.Context, db *sql.DB, prID string, reviewerIDs []string, explanation *models.AssignmentExplanation) error {
+	ctx, span := startSpan(ctx, "AddPullRequestReviewers")
+	defer span.End()
+
+	return inTx(ctx, db, func(tx *sql.Tx) error {
+		if err := lockOpenPullRequest(ctx, tx, prID); err != nil {
+			return err
+		}
+
+		var assignedAt time.Time
+		if err := tx.QueryRowContext(ctx, `SELECT NOW()`).Scan(&assignedAt); err != nil {
+			return err
+		}
+
+		for _, r := range reviewerIDs {
+			if _, err := tx.ExecContext(
+				ctx,
+				`INSERT INTO pull_request_reviewers (pull_request_id, reviewer_id, assigned_at) VALUES ($1, $2, $3)`,
+				prID,
+				r,
+				assignedAt,
+			); err != nil {
+				return err
+			}
+			if err := startReviewAssignment(ctx, tx, prID, r, assignedAt); err != nil {
+				return err
+			}
+		}
+
+		return insertAssignmentExplanation(ctx, tx, prID, explanation, assignedAt)
+	})
+}
+
+// RemovePullRequestReviewer unassigns the reviewer without a replacement and,
+// when it is not nil, stores the explanation. It fails with
+// ErrPullRequestNotOpen or ErrReviewerNotAssigned when the PR or the reviewer
+// changed concurrently.
+func RemovePullRequestReviewer(ctx context.Context, db *sql.DB, prID, reviewerID string, explanation *models.AssignmentExplanation) error {
+	ctx, span := startSpan(ctx, "RemovePullRequestReviewer")
+	defer span.End()
+
+	return inTx(ctx, db, func(tx *sql.Tx) error {
+		if err := lockOpenPullRequest(ctx, tx, prID); err != nil {
+			return err
+		}
+
+		var removedAt time.Time
+		if err := tx.QueryRowContext(ctx, `SELECT NOW()`).Scan(&removedAt); err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(
+			ctx,
+			`DELETE FROM pull_request_reviewers WHERE pull_request_id = $1 AND reviewer_id = $2`,
+			prID,
+			reviewerID,
+		)
+		if err := expectOneRow(res, err); err != nil {
+			return err
+		}
+
+		res, err = tx.ExecContext(
+			ctx,
+			`UPDATE review_assignments SET ended_at = $3, end_reason = 'removed'
+			WHERE pull_request_id = $1 AND reviewer_id = $2 AND ended_at IS NULL`,
+			prID,
+			reviewerID,
+			removedAt,
+		)
+		if err := expectOneRow(res, err); err != nil {
+			return err
+		}
+
+		return insertAssignmentExplanation(ctx, tx, prID, explanation, removedAt)
+	})
+}
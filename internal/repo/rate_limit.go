@@ -0,0 +1,69 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// refilledTokens is what the bucket holds now: the stored tokens plus those
+// earned since the last update, capped at the burst ($2) and refilled at
+// $3 tokens per second. It uses the database clock, so that replicas with
+// skewed clocks agree.
+const refilledTokens = `LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at)::float8 * $3::float8)`
+
+// TakeRateLimitToken takes a token from the bucket of key, creating a full
+// one first when there is none. It reports whether a token was taken and how
+// many are left; when none was taken, that is the current, fractional count.
+func TakeRateLimitToken(ctx context.Context, db *sql.DB, key string, burst, rate float64) (float64, bool, error) {
+	ctx, span := startSpan(ctx, "TakeRateLimitToken")
+	defer span.End()
+
+	query := fmt.Sprintf(`
+		INSERT INTO rate_limit_buckets AS b (key, tokens, updated_at, full_at)
+		VALUES ($1, $2::float8 - 1, NOW(), NOW() + make_interval(secs => 1 / $3::float8))
+		ON CONFLICT (key) DO UPDATE SET
+			tokens = %[1]s - 1,
+			updated_at = NOW(),
+			full_at = NOW() + make_interval(secs => ($2::float8 - %[1]s + 1) / $3::float8)
+		WHERE %[1]s >= 1
+		RETURNING tokens
+	`, refilledTokens)
+
+	// Not retried: after a lost connection the token may be taken by this
+	// very call, and a retry would take a second one.
+	var tokens float64
+	err := db.QueryRowContext(ctx, query, key, burst, rate).Scan(&tokens)
+	if err == nil {
+		return tokens, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, false, err
+	}
+
+	// The bucket exists and is empty.
+	query = fmt.Sprintf(`SELECT %s FROM rate_limit_buckets AS b WHERE b.key = $1`, refilledTokens)
+	if err := idempotent(db).QueryRowContext(ctx, query, key, burst, rate).Scan(&tokens); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// Only full buckets are deleted, so this one was removed by
+			// hand in between. Count it as empty for this request.
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	return tokens, false, nil
+}
+
+// DeleteFullRateLimitBuckets removes buckets that have refilled completely.
+// They are equal to the new bucket created on the next request.
+func DeleteFullRateLimitBuckets(ctx context.Context, db *sql.DB) (int64, error) {
+	ctx, span := startSpan(ctx, "DeleteFullRateLimitBuckets")
+	defer span.End()
+
+	res, err := idempotent(db).ExecContext(ctx, `DELETE FROM rate_limit_buckets WHERE full_at <= NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,157 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RetryPolicy says how often repo operations are run again after a
+// transient error. The delay starts at Backoff and doubles with every
+// attempt, with jitter.
+type RetryPolicy struct {
+	Attempts int
+	Backoff  time.Duration
+}
+
+var retryPolicy = RetryPolicy{Attempts: 3, Backoff: 50 * time.Millisecond}
+
+// SetRetryPolicy replaces the default policy of 3 attempts starting at 50ms.
+// It is meant to be called once at startup.
+func SetRetryPolicy(p RetryPolicy) {
+	retryPolicy = p
+}
+
+// retry runs op until it succeeds, fails with an error that is not
+// transient, or runs out of attempts.
+func retry(ctx context.Context, op func() error) error {
+	backoff := retryPolicy.Backoff
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil || attempt >= retryPolicy.Attempts || !isTransient(err) {
+			return err
+		}
+
+		delay := backoff/2 + rand.N(backoff/2+1)
+		slog.WarnContext(ctx, "retrying after transient database error",
+			"attempt", attempt,
+			"retry_in", delay,
+			"error", err,
+		)
+		trace.SpanFromContext(ctx).AddEvent("retry", trace.WithAttributes(
+			attribute.Int("attempt", attempt),
+			attribute.String("error", err.Error()),
+		))
+
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+		backoff *= 2
+	}
+}
+
+// idempotent marks statements that are safe to run twice: reads, and
+// writes that leave the same state when repeated. They are retried on
+// transient errors. Only running the statement is retried, not reading the
+// rows of a query.
+func idempotent(db *sql.DB) idempotentDB {
+	return idempotentDB{db}
+}
+
+type idempotentDB struct {
+	db *sql.DB
+}
+
+func (d idempotentDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := retry(ctx, func() error {
+		var err error
+		rows, err = d.db.QueryContext(ctx, query, args...)
+		return err
+	})
+	return rows, err
+}
+
+// QueryRowContext runs the query right away, so that its error can be
+// checked and retried before the row is scanned.
+func (d idempotentDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	var row *sql.Row
+	_ = retry(ctx, func() error {
+		row = d.db.QueryRowContext(ctx, query, args...)
+		return row.Err()
+	})
+	return row
+}
+
+func (d idempotentDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	var res sql.Result
+	err := retry(ctx, func() error {
+		var err error
+		res, err = d.db.ExecContext(ctx, query, args...)
+		return err
+	})
+	return res, err
+}
+
+// finalError stops retry for an error that would otherwise be transient.
+type finalError struct {
+	err error
+}
+
+func (e finalError) Error() string { return e.err.Error() }
+func (e finalError) Unwrap() error { return e.err }
+
+// isTransient reports whether running the operation again may succeed:
+// serialization failures and deadlocks, a lost or refused connection, or a
+// server that is shutting down or starting up.
+func isTransient(err error) bool {
+	var final finalError
+	if errors.As(err, &final) {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "57P01", "57P02", "57P03": // admin_shutdown, crash_shutdown, cannot_connect_now
+			return true
+		}
+		// transaction_rollback (serialization_failure 40001, deadlock_detected
+		// 40P01) and connection_exception.
+		return pqErr.Code.Class() == "40" || pqErr.Code.Class() == "08"
+	}
+
+	var netErr *net.OpError
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.As(err, &netErr)
+}
+
+// isRolledBack reports whether Postgres rolled the transaction back, so
+// nothing of it was applied.
+func isRolledBack(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code.Class() == "40"
+}
@@ -0,0 +1,63 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+)
+
+// GetReviewAssignments returns the assignment history for assignments made
+// within [from, to), optionally limited to PRs authored by teamName. The
+// team of an assignment is the team of the PR author.
+func GetReviewAssignments(ctx context.Context, db *sql.DB, from, to time.Time, teamName string) ([]models.ReviewAssignmentRecord, error) {
+	ctx, span := startSpan(ctx, "GetReviewAssignments")
+	defer span.End()
+
+	query := `
+		SELECT ra.pull_request_id, ra.reviewer_id, u.team_name, ra.assigned_at, ra.ended_at, ra.end_reason, ra.decline_reason
+		FROM review_assignments ra
+		JOIN pull_requests pr ON pr.id = ra.pull_request_id
+		JOIN users u ON u.id = pr.author_id
+		WHERE ra.assigned_at >= $1 AND ra.assigned_at < $2
+			AND ($3 = '' OR u.team_name = $3)
+		ORDER BY ra.assigned_at, ra.id
+	`
+
+	rows, err := idempotent(db).QueryContext(ctx, query, from, to, teamName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := make([]models.ReviewAssignmentRecord, 0)
+	for rows.Next() {
+		r, err := scanReviewAssignment(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+func scanReviewAssignment(row rowScanner) (models.ReviewAssignmentRecord, error) {
+	var r models.ReviewAssignmentRecord
+	var endedAt sql.NullTime
+	var endReason, declineReason sql.NullString
+	if err := row.Scan(&r.PullRequestID, &r.ReviewerID, &r.TeamName, &r.AssignedAt, &endedAt, &endReason, &declineReason); err != nil {
+		return r, err
+	}
+	if endedAt.Valid {
+		t := endedAt.Time
+		r.EndedAt = &t
+	}
+	r.EndReason = endReason.String
+	r.DeclineReason = declineReason.String
+	return r, nil
+}
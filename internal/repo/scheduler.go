@@ -0,0 +1,141 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+)
+
+// TryAdvisoryLock takes a session-level advisory lock on conn without
+// waiting. The lock is held until UnlockAdvisoryLock or until conn is closed.
+func TryAdvisoryLock(ctx context.Context, conn *sql.Conn, key int64) (bool, error) {
+	ctx, span := startSpan(ctx, "TryAdvisoryLock")
+	defer span.End()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&locked); err != nil {
+		return false, err
+	}
+	return locked, nil
+}
+
+func UnlockAdvisoryLock(ctx context.Context, conn *sql.Conn, key int64) error {
+	ctx, span := startSpan(ctx, "UnlockAdvisoryLock")
+	defer span.End()
+
+	_, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, key)
+	return err
+}
+
+// GetStalePullRequests returns OPEN PRs that have been open longer than the
+// threshold of the author's team (defaultThreshold when the team has none)
+// and were not reported within the last threshold.
+func GetStalePullRequests(ctx context.Context, db *sql.DB, defaultThreshold time.Duration) ([]models.StalePullRequest, error) {
+	ctx, span := startSpan(ctx, "GetStalePullRequests")
+	defer span.End()
+
+	query := `
+		WITH candidates AS (
+			SELECT
+				pr.id, pr.name, pr.author_id, pr.status, pr.created_at, pr.stale_notified_at,
+				u.team_name,
+				COALESCE(ts.stale_pr_threshold_hours * 3600, $1)::BIGINT AS threshold_seconds
+			FROM pull_requests pr
+			JOIN users u ON u.id = pr.author_id
+			LEFT JOIN team_settings ts ON ts.team_name = u.team_name
+			WHERE pr.status = 'OPEN'
+		)
+		SELECT
+			c.id, c.name, c.author_id, c.status, c.created_at, c.team_name, c.threshold_seconds,
+			ARRAY(
+				SELECT r.reviewer_id FROM pull_request_reviewers r
+				WHERE r.pull_request_id = c.id
+				ORDER BY r.reviewer_id
+			)
+		FROM candidates c
+		WHERE c.created_at < NOW() - make_interval(secs => c.threshold_seconds)
+			AND (c.stale_notified_at IS NULL
+				OR c.stale_notified_at < NOW() - make_interval(secs => c.threshold_seconds))
+		ORDER BY c.created_at
+	`
+
+	rows, err := idempotent(db).QueryContext(ctx, query, int64(defaultThreshold.Seconds()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prs := make([]models.StalePullRequest, 0)
+	for rows.Next() {
+		var pr models.StalePullRequest
+		var createdAt time.Time
+		var thresholdSeconds int64
+		if err := rows.Scan(
+			&pr.PullRequestID,
+			&pr.PullRequestName,
+			&pr.AuthorID,
+			&pr.Status,
+			&createdAt,
+			&pr.TeamName,
+			&thresholdSeconds,
+			pq.Array(&pr.AssignedReviewers),
+		); err != nil {
+			return nil, err
+		}
+		pr.CreatedAt = &createdAt
+		pr.Threshold = time.Duration(thresholdSeconds) * time.Second
+		prs = append(prs, pr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return prs, nil
+}
+
+func MarkPullRequestStaleNotified(ctx context.Context, db *sql.DB, id string) error {
+	ctx, span := startSpan(ctx, "MarkPullRequestStaleNotified")
+	defer span.End()
+
+	_, err := idempotent(db).ExecContext(ctx, `UPDATE pull_requests SET stale_notified_at = NOW() WHERE id = $1`, id)
+	return err
+}
+
+// GetAssignmentsHeldLongerThan returns reviewer assignments on OPEN PRs made
+// more than d ago.
+func GetAssignmentsHeldLongerThan(ctx context.Context, db *sql.DB, d time.Duration) ([]models.ReviewAssignment, error) {
+	ctx, span := startSpan(ctx, "GetAssignmentsHeldLongerThan")
+	defer span.End()
+
+	query := `
+		SELECT r.pull_request_id, r.reviewer_id, r.assigned_at
+		FROM pull_request_reviewers r
+		JOIN pull_requests pr ON pr.id = r.pull_request_id
+		WHERE pr.status = 'OPEN' AND r.assigned_at < NOW() - make_interval(secs => $1)
+		ORDER BY r.assigned_at
+	`
+
+	rows, err := idempotent(db).QueryContext(ctx, query, d.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	assignments := make([]models.ReviewAssignment, 0)
+	for rows.Next() {
+		var a models.ReviewAssignment
+		if err := rows.Scan(&a.PullRequestID, &a.ReviewerID, &a.AssignedAt); err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return assignments, nil
+}
@@ -0,0 +1,187 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+)
+
+func CountPullRequestsByStatus(ctx context.Context, db *sql.DB) (*models.PullRequestCounts, error) {
+	ctx, span := startSpan(ctx, "CountPullRequestsByStatus")
+	defer span.End()
+
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE status = 'OPEN'),
+			COUNT(*) FILTER (WHERE status = 'MERGED'),
+			COUNT(*) FILTER (WHERE status = 'CLOSED')
+		FROM pull_requests
+	`
+
+	counts := &models.PullRequestCounts{}
+	if err := idempotent(db).QueryRowContext(ctx, query).Scan(&counts.Open, &counts.Merged, &counts.Closed); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// GetReviewerLoad returns every user with the number of reviews assigned to
+// them, busiest reviewers first.
+func GetReviewerLoad(ctx context.Context, db *sql.DB) ([]models.ReviewerLoad, error) {
+	ctx, span := startSpan(ctx, "GetReviewerLoad")
+	defer span.End()
+
+	query := `
+		SELECT
+			u.id,
+			u.username,
+			u.team_name,
+			u.is_active,
+			COUNT(pr.id) FILTER (WHERE pr.status = 'OPEN'),
+			COUNT(pr.id)
+		FROM users u
+		LEFT JOIN pull_request_reviewers r ON r.reviewer_id = u.id
+		LEFT JOIN pull_requests pr ON pr.id = r.pull_request_id
+		GROUP BY u.id
+		ORDER BY 5 DESC, 6 DESC, u.id
+	`
+
+	rows, err := idempotent(db).QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	load := make([]models.ReviewerLoad, 0)
+	for rows.Next() {
+		var l models.ReviewerLoad
+		if err := rows.Scan(&l.UserID, &l.Username, &l.TeamName, &l.IsActive, &l.OpenReviews, &l.TotalReviews); err != nil {
+			return nil, err
+		}
+		load = append(load, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return load, nil
+}
+
+type keyedDurationStats struct {
+	key   string
+	stats models.DurationStats
+}
+
+// The three queries below only differ in the grouping key and the start of
+// the measured interval. They use the partial indexes on merged_at.
+
+func GetTeamMergeStats(ctx context.Context, db *sql.DB, from, to time.Time) ([]models.TeamMergeStats, error) {
+	ctx, span := startSpan(ctx, "GetTeamMergeStats")
+	defer span.End()
+
+	query := `
+		SELECT
+			u.team_name,
+			COUNT(*),
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM pr.merged_at - pr.created_at)),
+			percentile_cont(0.9) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM pr.merged_at - pr.created_at))
+		FROM pull_requests pr
+		JOIN users u ON u.id = pr.author_id
+		WHERE pr.status = 'MERGED' AND pr.merged_at >= $1 AND pr.merged_at < $2
+		GROUP BY u.team_name
+		ORDER BY u.team_name
+	`
+
+	rows, err := queryDurationStats(ctx, db, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]models.TeamMergeStats, 0, len(rows))
+	for _, r := range rows {
+		stats = append(stats, models.TeamMergeStats{TeamName: r.key, DurationStats: r.stats})
+	}
+	return stats, nil
+}
+
+func GetAuthorMergeStats(ctx context.Context, db *sql.DB, from, to time.Time) ([]models.AuthorMergeStats, error) {
+	ctx, span := startSpan(ctx, "GetAuthorMergeStats")
+	defer span.End()
+
+	query := `
+		SELECT
+			pr.author_id,
+			COUNT(*),
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM pr.merged_at - pr.created_at)),
+			percentile_cont(0.9) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM pr.merged_at - pr.created_at))
+		FROM pull_requests pr
+		WHERE pr.status = 'MERGED' AND pr.merged_at >= $1 AND pr.merged_at < $2
+		GROUP BY pr.author_id
+		ORDER BY pr.author_id
+	`
+
+	rows, err := queryDurationStats(ctx, db, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]models.AuthorMergeStats, 0, len(rows))
+	for _, r := range rows {
+		stats = append(stats, models.AuthorMergeStats{AuthorID: r.key, DurationStats: r.stats})
+	}
+	return stats, nil
+}
+
+func GetReviewerMergeStats(ctx context.Context, db *sql.DB, from, to time.Time) ([]models.ReviewerMergeStats, error) {
+	ctx, span := startSpan(ctx, "GetReviewerMergeStats")
+	defer span.End()
+
+	query := `
+		SELECT
+			r.reviewer_id,
+			COUNT(*),
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM pr.merged_at - r.assigned_at)),
+			percentile_cont(0.9) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM pr.merged_at - r.assigned_at))
+		FROM pull_requests pr
+		JOIN pull_request_reviewers r ON r.pull_request_id = pr.id
+		WHERE pr.status = 'MERGED' AND pr.merged_at >= $1 AND pr.merged_at < $2
+		GROUP BY r.reviewer_id
+		ORDER BY r.reviewer_id
+	`
+
+	rows, err := queryDurationStats(ctx, db, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]models.ReviewerMergeStats, 0, len(rows))
+	for _, r := range rows {
+		stats = append(stats, models.ReviewerMergeStats{ReviewerID: r.key, DurationStats: r.stats})
+	}
+	return stats, nil
+}
+
+func queryDurationStats(ctx context.Context, db *sql.DB, query string, from, to time.Time) ([]keyedDurationStats, error) {
+	rows, err := idempotent(db).QueryContext(ctx, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]keyedDurationStats, 0)
+	for rows.Next() {
+		var r keyedDurationStats
+		if err := rows.Scan(&r.key, &r.stats.Count, &r.stats.MedianSeconds, &r.stats.P90Seconds); err != nil {
+			return nil, err
+		}
+		res = append(res, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -1,17 +1,21 @@
 package repo
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 
 	"github.com/Wucop228/avito-PullRequest/internal/models"
 )
 
-func GetTeamByName(db *sql.DB, name string) (*models.Teams, error) {
+func GetTeamByName(ctx context.Context, db *sql.DB, name string) (*models.Teams, error) {
+	ctx, span := startSpan(ctx, "GetTeamByName")
+	defer span.End()
+
 	query := "SELECT id, name FROM teams WHERE name=$1"
 
 	team := &models.Teams{}
-	err := db.QueryRow(query, name).Scan(&team.ID, &team.Name)
+	err := idempotent(db).QueryRowContext(ctx, query, name).Scan(&team.ID, &team.Name)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -22,44 +26,46 @@ func GetTeamByName(db *sql.DB, name string) (*models.Teams, error) {
 	return team, nil
 }
 
-func CreateTeamWithMembers(db *sql.DB, team *models.RequestTeamAdd) error {
-	tx, err := db.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
+func CreateTeamWithMembers(ctx context.Context, db *sql.DB, team *models.RequestTeamAdd) error {
+	ctx, span := startSpan(ctx, "CreateTeamWithMembers")
+	defer span.End()
 
-	query := "INSERT INTO teams (name) VALUES ($1)"
-	_, err = tx.Exec(query, team.TeamName)
-	if err != nil {
-		return err
-	}
-
-	query = `
-		INSERT INTO users (id, username, team_name, is_active)
-		VALUES ($1, $2, $3, $4)
-		ON CONFLICT (id) DO UPDATE
-		SET username = EXCLUDED.username,
-			team_name = EXCLUDED.team_name,
-			is_active = EXCLUDED.is_active
-	`
-	stmt, err := tx.Prepare(query)
-	if err != nil {
-		return err
-	}
-	defer stmt.Close()
+	return inTx(ctx, db, func(tx *sql.Tx) error {
+		query := "INSERT INTO teams (name) VALUES ($1)"
+		_, err := tx.ExecContext(ctx, query, team.TeamName)
+		if err != nil {
+			return err
+		}
 
-	for _, member := range team.Members {
-		if _, err := stmt.Exec(member.UserID, member.Username, team.TeamName, member.IsActive); err != nil {
+		query = `
+			INSERT INTO users (id, username, team_name, is_active)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (id) DO UPDATE
+			SET username = EXCLUDED.username,
+				team_name = EXCLUDED.team_name,
+				is_active = EXCLUDED.is_active
+		`
+		stmt, err := tx.PrepareContext(ctx, query)
+		if err != nil {
 			return err
 		}
-	}
+		defer stmt.Close()
 
-	return tx.Commit()
+		for _, member := range team.Members {
+			if _, err := stmt.ExecContext(ctx, member.UserID, member.Username, team.TeamName, member.IsActive); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 }
 
-func GetTeamWithMembers(db *sql.DB, name string) (*models.RequestTeamAdd, error) {
-	team, err := GetTeamByName(db, name)
+func GetTeamWithMembers(ctx context.Context, db *sql.DB, name string) (*models.RequestTeamAdd, error) {
+	ctx, span := startSpan(ctx, "GetTeamWithMembers")
+	defer span.End()
+
+	team, err := GetTeamByName(ctx, db, name)
 	if err != nil {
 		return nil, err
 	}
@@ -68,7 +74,7 @@ func GetTeamWithMembers(db *sql.DB, name string) (*models.RequestTeamAdd, error)
 	}
 
 	query := "SELECT id, username, is_active FROM users WHERE team_name=$1"
-	rows, err := db.Query(query, name)
+	rows, err := idempotent(db).QueryContext(ctx, query, name)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,153 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+)
+
+const teamSettingsColumns = `
+	team_name, stale_pr_threshold_hours, review_sla_hours,
+	work_days, work_start_hour, work_end_hour, timezone,
+	monthly_decline_quota
+`
+
+func UpsertTeamSettings(ctx context.Context, db *sql.DB, settings *models.TeamSettings) error {
+	ctx, span := startSpan(ctx, "UpsertTeamSettings")
+	defer span.End()
+
+	query := `
+		INSERT INTO team_settings (` + teamSettingsColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (team_name) DO UPDATE
+		SET stale_pr_threshold_hours = EXCLUDED.stale_pr_threshold_hours,
+			review_sla_hours = EXCLUDED.review_sla_hours,
+			work_days = EXCLUDED.work_days,
+			work_start_hour = EXCLUDED.work_start_hour,
+			work_end_hour = EXCLUDED.work_end_hour,
+			timezone = EXCLUDED.timezone,
+			monthly_decline_quota = EXCLUDED.monthly_decline_quota
+	`
+
+	var workDays interface{}
+	if settings.WorkDays != nil {
+		days := make(pq.Int64Array, len(settings.WorkDays))
+		for i, d := range settings.WorkDays {
+			days[i] = int64(d)
+		}
+		workDays = days
+	}
+
+	_, err := idempotent(db).ExecContext(
+		ctx,
+		query,
+		settings.TeamName,
+		settings.StalePRThresholdHours,
+		settings.ReviewSLAHours,
+		workDays,
+		settings.WorkStartHour,
+		settings.WorkEndHour,
+		settings.Timezone,
+		settings.MonthlyDeclineQuota,
+	)
+	return err
+}
+
+// GetTeamSettings returns empty settings for teams that never changed them.
+func GetTeamSettings(ctx context.Context, db *sql.DB, teamName string) (*models.TeamSettings, error) {
+	ctx, span := startSpan(ctx, "GetTeamSettings")
+	defer span.End()
+
+	query := "SELECT " + teamSettingsColumns + " FROM team_settings WHERE team_name = $1"
+
+	settings, err := scanTeamSettings(idempotent(db).QueryRowContext(ctx, query, teamName))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &models.TeamSettings{TeamName: teamName}, nil
+		}
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+// GetAllTeamSettings returns the settings of every team that has any, keyed
+// by team name.
+func GetAllTeamSettings(ctx context.Context, db *sql.DB) (map[string]*models.TeamSettings, error) {
+	ctx, span := startSpan(ctx, "GetAllTeamSettings")
+	defer span.End()
+
+	rows, err := idempotent(db).QueryContext(ctx, "SELECT "+teamSettingsColumns+" FROM team_settings")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	all := make(map[string]*models.TeamSettings)
+	for rows.Next() {
+		settings, err := scanTeamSettings(rows)
+		if err != nil {
+			return nil, err
+		}
+		all[settings.TeamName] = settings
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return all, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTeamSettings(row rowScanner) (*models.TeamSettings, error) {
+	var settings models.TeamSettings
+	var staleHours, slaHours, startHour, endHour, declineQuota sql.NullInt32
+	var workDays pq.Int64Array
+	var timezone sql.NullString
+
+	if err := row.Scan(
+		&settings.TeamName,
+		&staleHours,
+		&slaHours,
+		&workDays,
+		&startHour,
+		&endHour,
+		&timezone,
+		&declineQuota,
+	); err != nil {
+		return nil, err
+	}
+
+	settings.StalePRThresholdHours = nullIntPtr(staleHours)
+	settings.ReviewSLAHours = nullIntPtr(slaHours)
+	settings.WorkStartHour = nullIntPtr(startHour)
+	settings.WorkEndHour = nullIntPtr(endHour)
+	settings.MonthlyDeclineQuota = nullIntPtr(declineQuota)
+	if workDays != nil {
+		settings.WorkDays = make([]int, len(workDays))
+		for i, d := range workDays {
+			settings.WorkDays[i] = int(d)
+		}
+	}
+	if timezone.Valid {
+		tz := timezone.String
+		settings.Timezone = &tz
+	}
+
+	return &settings, nil
+}
+
+func nullIntPtr(v sql.NullInt32) *int {
+	if !v.Valid {
+		return nil
+	}
+	i := int(v.Int32)
+	return &i
+}
@@ -0,0 +1,18 @@
+package repo
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/Wucop228/avito-PullRequest/internal/repo")
+
+// startSpan starts the span of a repo function, named after it. The
+// statements the function runs are its children, recorded by the
+// instrumented driver with their SQL.
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "repo."+name, trace.WithAttributes(semconv.DBSystemNamePostgreSQL))
+}
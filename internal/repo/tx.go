@@ -0,0 +1,43 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+)
+
+// rollback is deferred after BeginTx. After a successful Commit it is a
+// no-op; other failures are logged since the caller is already returning an
+// error of its own.
+func rollback(ctx context.Context, tx *sql.Tx) {
+	if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		slog.WarnContext(ctx, "failed to roll back transaction", "error", err)
+	}
+}
+
+// inTx runs fn in a transaction and commits it. The whole transaction is run
+// again on a transient error, since Postgres has rolled it back by then.
+// A commit that fails with a lost connection is not retried: it may have
+// been applied.
+func inTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	return retry(ctx, func() error {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer rollback(ctx, tx)
+
+		if err := fn(tx); err != nil {
+			return err
+		}
+
+		if err := tx.Commit(); err != nil {
+			if !isRolledBack(err) {
+				return finalError{err}
+			}
+			return err
+		}
+		return nil
+	})
+}
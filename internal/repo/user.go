@@ -1,17 +1,21 @@
 package repo
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 
 	"github.com/Wucop228/avito-PullRequest/internal/models"
 )
 
-func UpdateUserIsActive(db *sql.DB, userID string, isActive bool) (*models.User, error) {
+func UpdateUserIsActive(ctx context.Context, db *sql.DB, userID string, isActive bool) (*models.User, error) {
+	ctx, span := startSpan(ctx, "UpdateUserIsActive")
+	defer span.End()
+
 	query := "UPDATE users SET is_active = $2 WHERE id = $1 RETURNING id, username, team_name, is_active"
 
 	user := &models.User{}
-	err := db.QueryRow(query, userID, isActive).Scan(
+	err := idempotent(db).QueryRowContext(ctx, query, userID, isActive).Scan(
 		&user.UserID,
 		&user.Username,
 		&user.TeamName,
@@ -24,11 +28,14 @@ func UpdateUserIsActive(db *sql.DB, userID string, isActive bool) (*models.User,
 	return user, nil
 }
 
-func GetUserByID(db *sql.DB, userID string) (*models.User, error) {
+func GetUserByID(ctx context.Context, db *sql.DB, userID string) (*models.User, error) {
+	ctx, span := startSpan(ctx, "GetUserByID")
+	defer span.End()
+
 	query := "SELECT id, username, team_name, is_active FROM users WHERE id = $1"
 
 	user := &models.User{}
-	err := db.QueryRow(query, userID).Scan(
+	err := idempotent(db).QueryRowContext(ctx, query, userID).Scan(
 		&user.UserID,
 		&user.Username,
 		&user.TeamName,
@@ -43,28 +50,3 @@ func GetUserByID(db *sql.DB, userID string) (*models.User, error) {
 
 	return user, nil
 }
-
-func GetActiveUsersByTeam(db *sql.DB, teamName string) ([]models.User, error) {
-	query := "SELECT id, username, team_name, is_active FROM users WHERE team_name = $1 AND is_active = TRUE"
-
-	rows, err := db.Query(query, teamName)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	users := make([]models.User, 0)
-	for rows.Next() {
-		var u models.User
-		if err := rows.Scan(&u.UserID, &u.Username, &u.TeamName, &u.IsActive); err != nil {
-			return nil, err
-		}
-		users = append(users, u)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return users, nil
-}
@@ -0,0 +1,68 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+)
+
+// InsertUserToken stores the hash of a new API token of the user.
+func InsertUserToken(ctx context.Context, db *sql.DB, tokenHash, userID string) error {
+	ctx, span := startSpan(ctx, "InsertUserToken")
+	defer span.End()
+
+	// Not retried: a lost connection may leave the token stored, and a retry
+	// would fail on the duplicate.
+	_, err := db.ExecContext(
+		ctx,
+		`INSERT INTO user_tokens (token_hash, user_id) VALUES ($1, $2)`,
+		tokenHash,
+		userID,
+	)
+	return err
+}
+
+// GetUserByTokenHash returns the owner of the token, or nil when there is no
+// such token.
+func GetUserByTokenHash(ctx context.Context, db *sql.DB, tokenHash string) (*models.User, error) {
+	ctx, span := startSpan(ctx, "GetUserByTokenHash")
+	defer span.End()
+
+	query := `
+		SELECT u.id, u.username, u.team_name, u.is_active
+		FROM user_tokens t
+		JOIN users u ON u.id = t.user_id
+		WHERE t.token_hash = $1
+	`
+
+	user := &models.User{}
+	err := idempotent(db).QueryRowContext(ctx, query, tokenHash).Scan(
+		&user.UserID,
+		&user.Username,
+		&user.TeamName,
+		&user.IsActive,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// DeleteUserTokens revokes all tokens of the user and returns how many there
+// were.
+func DeleteUserTokens(ctx context.Context, db *sql.DB, userID string) (int64, error) {
+	ctx, span := startSpan(ctx, "DeleteUserTokens")
+	defer span.End()
+
+	res, err := idempotent(db).ExecContext(ctx, `DELETE FROM user_tokens WHERE user_id = $1`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,176 @@
+// Package scheduler runs the periodic background jobs of the service.
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel"
+
+	"github.com/Wucop228/avito-PullRequest/internal/events"
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+	"github.com/Wucop228/avito-PullRequest/internal/repo"
+	"github.com/Wucop228/avito-PullRequest/internal/service"
+)
+
+var tracer = otel.Tracer("github.com/Wucop228/avito-PullRequest/internal/scheduler")
+
+// lockKey is the advisory lock that makes only one replica run a tick.
+const lockKey int64 = 0x70725f7374616c65 // "pr_stale"
+
+type Config struct {
+	Interval time.Duration
+	// StalePRThreshold applies to teams without their own threshold.
+	StalePRThreshold time.Duration
+	// ReassignAfter replaces reviewers who have held an assignment on an
+	// open PR longer than this. Zero disables it.
+	ReassignAfter time.Duration
+	// EventRetention prunes older events from the event log. Zero disables
+	// it.
+	EventRetention time.Duration
+}
+
+type Scheduler struct {
+	db    *sql.DB
+	prSvc *service.PullRequestService
+	sink  events.Sink
+	cfg   Config
+}
+
+func New(db *sql.DB, prSvc *service.PullRequestService, sink events.Sink, cfg Config) *Scheduler {
+	return &Scheduler{db: db, prSvc: prSvc, sink: sink, cfg: cfg}
+}
+
+// Run executes the jobs every interval until ctx is cancelled. A tick that
+// is in progress when ctx is cancelled is allowed to finish.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.tick(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				slog.ErrorContext(ctx, "scheduler tick failed", "error", err)
+			}
+		}
+	}
+}
+
+func (s *Scheduler) tick(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "scheduler.tick")
+	defer span.End()
+
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	locked, err := repo.TryAdvisoryLock(ctx, conn, lockKey)
+	if err != nil {
+		return err
+	}
+	if !locked {
+		return nil
+	}
+	defer func() {
+		if err := repo.UnlockAdvisoryLock(context.Background(), conn, lockKey); err != nil {
+			slog.ErrorContext(ctx, "scheduler failed to release lock", "error", err)
+		}
+	}()
+
+	if err := s.notifyStale(ctx); err != nil {
+		return err
+	}
+
+	if s.cfg.ReassignAfter > 0 {
+		if err := s.reassignLongHeld(ctx); err != nil {
+			return err
+		}
+	}
+
+	if s.cfg.EventRetention > 0 {
+		if _, err := repo.DeleteEventsBefore(ctx, s.db, time.Now().Add(-s.cfg.EventRetention)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// notifyStale emits pr.stale for every PR open longer than its team's
+// threshold, repeating the reminder once per threshold while it stays open.
+func (s *Scheduler) notifyStale(ctx context.Context) error {
+	prs, err := repo.GetStalePullRequests(ctx, s.db, s.cfg.StalePRThreshold)
+	if err != nil {
+		return err
+	}
+
+	for _, pr := range prs {
+		userIDs := append([]string{pr.AuthorID}, pr.AssignedReviewers...)
+		event := events.New(events.TypePullRequestStale, pr.TeamName, userIDs, stalePayload{
+			PullRequest:      pr.PullRequest,
+			TeamName:         pr.TeamName,
+			OpenForSeconds:   int64(time.Since(*pr.CreatedAt).Seconds()),
+			ThresholdSeconds: int64(pr.Threshold.Seconds()),
+		})
+
+		// Webhook errors are only logged by the sink, so an error here means
+		// the event was not stored and the reminder is retried next tick.
+		if err := s.sink.Publish(ctx, event); err != nil {
+			slog.ErrorContext(ctx, "scheduler failed to publish event",
+				"type", event.Type,
+				"pull_request_id", pr.PullRequestID,
+				"error", err,
+			)
+			continue
+		}
+
+		if err := repo.MarkPullRequestStaleNotified(ctx, s.db, pr.PullRequestID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Scheduler) reassignLongHeld(ctx context.Context) error {
+	assignments, err := repo.GetAssignmentsHeldLongerThan(ctx, s.db, s.cfg.ReassignAfter)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range assignments {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		reason := "assignment held longer than " + s.cfg.ReassignAfter.String()
+		_, _, err := s.prSvc.ReassignReviewerWithReason(ctx, a.PullRequestID, a.ReviewerID, reason)
+		if err != nil {
+			// Nobody to hand the review over to, or the PR changed meanwhile.
+			if errors.Is(err, service.ErrNoCandidate) ||
+				errors.Is(err, service.ErrReviewerNotAssigned) ||
+				errors.Is(err, service.ErrPRMerged) ||
+				errors.Is(err, service.ErrPRNotOpen) {
+				continue
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+type stalePayload struct {
+	models.PullRequest
+	TeamName         string `json:"team_name"`
+	OpenForSeconds   int64  `json:"open_for_seconds"`
+	ThresholdSeconds int64  `json:"threshold_seconds"`
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"path"
+	"strings"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+)
+
+// codeOwnersFor returns the owners of the changed files in first-seen order.
+// As in CODEOWNERS, the last rule matching a file wins for that file.
+func codeOwnersFor(rules []models.CodeOwnerRule, files []string) []string {
+	seen := make(map[string]struct{})
+	owners := make([]string, 0)
+
+	for _, file := range files {
+		file = strings.TrimPrefix(file, "/")
+		for i := len(rules) - 1; i >= 0; i-- {
+			if !matchCodeOwnerPattern(rules[i].Pattern, file) {
+				continue
+			}
+			for _, owner := range rules[i].Owners {
+				if _, ok := seen[owner]; ok {
+					continue
+				}
+				seen[owner] = struct{}{}
+				owners = append(owners, owner)
+			}
+			break
+		}
+	}
+
+	return owners
+}
+
+// matchCodeOwnerPattern follows the gitignore-like CODEOWNERS syntax: a
+// leading "/" anchors the pattern to the repository root, a pattern without
+// any other "/" matches at any depth, "**" matches any number of directories
+// and a pattern naming a directory matches everything below it.
+func matchCodeOwnerPattern(pattern, file string) bool {
+	anchored := strings.HasPrefix(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	if pattern == "" {
+		return false
+	}
+
+	if !anchored && !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+
+	return globMatch(strings.Split(pattern, "/"), strings.Split(file, "/")) ||
+		globMatch(strings.Split(pattern+"/**", "/"), strings.Split(file, "/"))
+}
+
+func globMatch(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(parts); i++ {
+				if globMatch(pattern[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(parts) == 0 {
+			return false
+		}
+		ok, err := path.Match(pattern[0], parts[0])
+		if err != nil || !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+
+	return len(parts) == 0
+}
+
+func validCodeOwnerPattern(pattern string) bool {
+	trimmed := strings.Trim(pattern, "/")
+	if trimmed == "" {
+		return false
+	}
+	for _, segment := range strings.Split(trimmed, "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			return false
+		}
+	}
+	return true
+}
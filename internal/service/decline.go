@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+	"github.com/Wucop228/avito-PullRequest/internal/repo"
+)
+
+var ErrDeclineQuotaExceeded = errors.New("monthly decline quota exceeded")
+
+// ReasonDeclined is the reason of replacements made because the reviewer
+// declined the review.
+const ReasonDeclined = "declined"
+
+// reviewDecline is a reviewer declining their review, within quota declines
+// since the start of the month.
+type reviewDecline struct {
+	reason string
+	quota  int
+	since  time.Time
+	// declined is how many reviews the reviewer declined this month,
+	// counting this one, once it went through.
+	declined int
+}
+
+// DeclineReview hands reviewerID's review of an open PR to another reviewer,
+// picked like ReassignReviewer does, and keeps the reason in the assignment
+// history. Every user may decline a number of reviews per calendar month set
+// by their team, or by DeclineQuota. It returns the new reviewer and how many
+// more reviews the user may decline this month.
+func (s *PullRequestService) DeclineReview(ctx context.Context, prID, reviewerID, reason string) (*models.PullRequest, string, int, error) {
+	ctx, span := tracer.Start(ctx, "PullRequestService.DeclineReview")
+	defer span.End()
+
+	pr, err := s.openPullRequest(ctx, prID)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	if !slices.Contains(pr.AssignedReviewers, reviewerID) {
+		return nil, "", 0, ErrReviewerNotAssigned
+	}
+
+	quota, since, err := s.declineQuota(ctx, reviewerID)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	// Checked up front so that a user over the quota hears about it rather
+	// than about a missing replacement. The decline itself checks again.
+	declined, err := repo.CountDeclinedReviews(ctx, s.db, reviewerID, since)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	if declined >= quota {
+		return nil, "", 0, ErrDeclineQuotaExceeded
+	}
+
+	decline := &reviewDecline{reason: reason, quota: quota, since: since}
+	pr, newReviewerID, _, err := s.reassignReviewer(ctx, prID, reviewerID, ReasonDeclined, decline)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	return pr, newReviewerID, quota - decline.declined, nil
+}
+
+// declineQuota returns the user's monthly decline quota and the start of the
+// current month in their team's timezone.
+func (s *PullRequestService) declineQuota(ctx context.Context, userID string) (int, time.Time, error) {
+	user, err := repo.GetUserByID(ctx, s.db, userID)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	if user == nil {
+		return 0, time.Time{}, ErrUserNotFound
+	}
+
+	settings, err := repo.GetTeamSettings(ctx, s.db, user.TeamName)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	quota := s.cfg.DeclineQuota
+	if settings.MonthlyDeclineQuota != nil {
+		quota = *settings.MonthlyDeclineQuota
+	}
+
+	_, cal, err := reviewSLA(settings)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	return quota, monthStart(time.Now(), cal.loc), nil
+}
+
+func monthStart(t time.Time, loc *time.Location) time.Time {
+	y, m, _ := t.In(loc).Date()
+	return time.Date(y, m, 1, 0, 0, 0, 0, loc)
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Wucop228/avito-PullRequest/internal/events"
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+	"github.com/Wucop228/avito-PullRequest/internal/repo"
+)
+
+type EventService struct {
+	db     *sql.DB
+	broker *events.Broker
+}
+
+func NewEventService(db *sql.DB, broker *events.Broker) *EventService {
+	return &EventService{db: db, broker: broker}
+}
+
+// Subscribe follows new events matching the filter. It returns
+// events.ErrBrokerClosed once the service is shutting down.
+func (s *EventService) Subscribe(filter models.EventFilter) (*events.Subscription, error) {
+	return s.broker.Subscribe(filter)
+}
+
+// GetEventsAfter reads the persisted event log for Last-Event-ID resume.
+func (s *EventService) GetEventsAfter(ctx context.Context, afterID int64, filter models.EventFilter, limit int) ([]models.StoredEvent, error) {
+	ctx, span := tracer.Start(ctx, "EventService.GetEventsAfter")
+	defer span.End()
+
+	return repo.GetEventsAfter(ctx, s.db, afterID, filter, limit)
+}
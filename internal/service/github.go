@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+	"github.com/Wucop228/avito-PullRequest/internal/repo"
+)
+
+const (
+	WebhookResultCreated  = "created"
+	WebhookResultExists   = "exists"
+	WebhookResultMerged   = "merged"
+	WebhookResultClosed   = "closed"
+	WebhookResultReopened = "reopened"
+	WebhookResultIgnored  = "ignored"
+)
+
+type GitHubService struct {
+	db    *sql.DB
+	prSvc *PullRequestService
+}
+
+func NewGitHubService(db *sql.DB, prSvc *PullRequestService) *GitHubService {
+	return &GitHubService{db: db, prSvc: prSvc}
+}
+
+// HandlePullRequestEvent applies a GitHub pull_request webhook to the service.
+// Every action is safe to replay, so redelivered webhooks do not fail or
+// create duplicates.
+func (s *GitHubService) HandlePullRequestEvent(ctx context.Context, event *models.GitHubPullRequestEvent) (*models.PullRequest, string, error) {
+	ctx, span := tracer.Start(ctx, "GitHubService.HandlePullRequestEvent")
+	defer span.End()
+
+	switch event.Action {
+	case "opened", "reopened", "ready_for_review":
+		if event.PullRequest.Draft {
+			return nil, WebhookResultIgnored, nil
+		}
+		return s.ensurePullRequest(ctx, event)
+	case "closed":
+		if !event.PullRequest.Merged {
+			return nil, WebhookResultIgnored, nil
+		}
+		if _, _, err := s.ensurePullRequest(ctx, event); err != nil {
+			return nil, "", err
+		}
+		pr, err := s.prSvc.MergePullRequest(ctx, GitHubPullRequestID(event))
+		if err != nil {
+			return nil, "", err
+		}
+		return pr, WebhookResultMerged, nil
+	}
+
+	return nil, WebhookResultIgnored, nil
+}
+
+func (s *GitHubService) ensurePullRequest(ctx context.Context, event *models.GitHubPullRequestEvent) (*models.PullRequest, string, error) {
+	prID := GitHubPullRequestID(event)
+
+	existing, err := repo.GetPullRequestWithReviewers(ctx, s.db, prID)
+	if err != nil {
+		return nil, "", err
+	}
+	if existing != nil {
+		return existing, WebhookResultExists, nil
+	}
+
+	authorID, err := resolveExternalLogin(ctx, s.db, ProviderGitHub, event.PullRequest.User.Login)
+	if err != nil {
+		return nil, "", err
+	}
+
+	pr, err := s.prSvc.CreatePullRequest(ctx, &models.RequestPullRequestCreate{
+		PullRequestID:   prID,
+		PullRequestName: event.PullRequest.Title,
+		AuthorID:        authorID,
+	})
+	if err != nil {
+		// A concurrent delivery of the same event may have created it first.
+		if errors.Is(err, ErrPRExists) {
+			existing, err := repo.GetPullRequestWithReviewers(ctx, s.db, prID)
+			if err != nil {
+				return nil, "", err
+			}
+			return existing, WebhookResultExists, nil
+		}
+		return nil, "", err
+	}
+
+	return pr, WebhookResultCreated, nil
+}
+
+// GitHubPullRequestID builds a pull_request_id that is unique across repositories.
+func GitHubPullRequestID(event *models.GitHubPullRequestEvent) string {
+	number := event.PullRequest.Number
+	if number == 0 {
+		number = event.Number
+	}
+	return fmt.Sprintf("%s#%d", event.Repository.FullName, number)
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+	"github.com/Wucop228/avito-PullRequest/internal/repo"
+)
+
+type GitLabService struct {
+	db    *sql.DB
+	prSvc *PullRequestService
+}
+
+func NewGitLabService(db *sql.DB, prSvc *PullRequestService) *GitLabService {
+	return &GitLabService{db: db, prSvc: prSvc}
+}
+
+// HandleMergeRequestEvent applies a GitLab "Merge Request Hook" to the service.
+// Like the GitHub hook, every action is safe to replay.
+func (s *GitLabService) HandleMergeRequestEvent(ctx context.Context, event *models.GitLabMergeRequestEvent) (*models.PullRequest, string, error) {
+	ctx, span := tracer.Start(ctx, "GitLabService.HandleMergeRequestEvent")
+	defer span.End()
+
+	slog.DebugContext(ctx, "gitlab webhook received",
+		"action", event.ObjectAttributes.Action,
+		"project_id", event.Project.ID,
+		"iid", event.ObjectAttributes.IID,
+	)
+
+	switch event.ObjectAttributes.Action {
+	case "open":
+		return s.ensurePullRequest(ctx, event)
+	case "reopen":
+		pr, result, err := s.ensurePullRequest(ctx, event)
+		if err != nil || result == WebhookResultIgnored {
+			return nil, result, err
+		}
+		pr, err = s.prSvc.ReopenPullRequest(ctx, pr.PullRequestID)
+		if err != nil {
+			return nil, "", err
+		}
+		return pr, WebhookResultReopened, nil
+	case "merge":
+		pr, result, err := s.ensurePullRequest(ctx, event)
+		if err != nil || result == WebhookResultIgnored {
+			return nil, result, err
+		}
+		pr, err = s.prSvc.MergePullRequest(ctx, pr.PullRequestID)
+		if err != nil {
+			return nil, "", err
+		}
+		return pr, WebhookResultMerged, nil
+	case "close":
+		prID, err := repo.GetPullRequestIDByGitLabMergeRequest(ctx, s.db, event.Project.ID, event.ObjectAttributes.IID)
+		if err != nil {
+			return nil, "", err
+		}
+		if prID == "" {
+			return nil, WebhookResultIgnored, nil
+		}
+		pr, err := s.prSvc.ClosePullRequest(ctx, prID)
+		if err != nil {
+			return nil, "", err
+		}
+		return pr, WebhookResultClosed, nil
+	}
+
+	return nil, WebhookResultIgnored, nil
+}
+
+// ensurePullRequest returns the PR of the MR, creating it when the MR has not
+// been seen yet. An unseen MR is ignored unless the event was triggered by its
+// author.
+func (s *GitLabService) ensurePullRequest(ctx context.Context, event *models.GitLabMergeRequestEvent) (*models.PullRequest, string, error) {
+	projectID, iid := event.Project.ID, event.ObjectAttributes.IID
+
+	prID, err := repo.GetPullRequestIDByGitLabMergeRequest(ctx, s.db, projectID, iid)
+	if err != nil {
+		return nil, "", err
+	}
+	if prID != "" {
+		existing, err := repo.GetPullRequestWithReviewers(ctx, s.db, prID)
+		if err != nil {
+			return nil, "", err
+		}
+		if existing != nil {
+			return existing, WebhookResultExists, nil
+		}
+	}
+
+	// The hook carries the numeric author id only, while logins are mapped by
+	// username. The username is known for the user who triggered the event,
+	// which is the author on open but may be anyone on merge or reopen.
+	if event.User.ID != event.ObjectAttributes.AuthorID {
+		slog.InfoContext(ctx, "gitlab merge request of unknown author ignored",
+			"project_id", projectID,
+			"iid", iid,
+			"action", event.ObjectAttributes.Action,
+		)
+		return nil, WebhookResultIgnored, nil
+	}
+
+	authorID, err := resolveExternalLogin(ctx, s.db, ProviderGitLab, event.User.Username)
+	if err != nil {
+		return nil, "", err
+	}
+
+	prID = GitLabPullRequestID(projectID, iid)
+	result := WebhookResultCreated
+
+	pr, err := s.prSvc.CreatePullRequest(ctx, &models.RequestPullRequestCreate{
+		PullRequestID:   prID,
+		PullRequestName: event.ObjectAttributes.Title,
+		AuthorID:        authorID,
+	})
+	if err != nil {
+		// Created by an earlier delivery that failed before linking the MR.
+		if !errors.Is(err, ErrPRExists) {
+			return nil, "", err
+		}
+		pr, err = repo.GetPullRequestWithReviewers(ctx, s.db, prID)
+		if err != nil {
+			return nil, "", err
+		}
+		result = WebhookResultExists
+	}
+
+	if err := repo.LinkGitLabMergeRequest(ctx, s.db, projectID, iid, prID); err != nil {
+		return nil, "", err
+	}
+
+	return pr, result, nil
+}
+
+// GitLabPullRequestID builds a pull_request_id from the project id and MR iid,
+// since iids are only unique within a project.
+func GitLabPullRequestID(projectID, iid int64) string {
+	return fmt.Sprintf("gitlab:%d!%d", projectID, iid)
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+	"github.com/Wucop228/avito-PullRequest/internal/repo"
+)
+
+var (
+	ErrIdempotencyKeyReused     = errors.New("idempotency key was used with a different request")
+	ErrIdempotencyKeyInProgress = errors.New("request with this idempotency key is in progress")
+)
+
+type IdempotencyService struct {
+	db  *sql.DB
+	ttl time.Duration
+}
+
+func NewIdempotencyService(db *sql.DB, ttl time.Duration) *IdempotencyService {
+	return &IdempotencyService{db: db, ttl: ttl}
+}
+
+// Begin reserves the key for the request. It returns the stored record when
+// the same request was already completed, and nil when the caller should
+// process the request and then call Complete or Release.
+func (s *IdempotencyService) Begin(ctx context.Context, key, method, path string, body []byte) (*models.IdempotencyRecord, error) {
+	ctx, span := tracer.Start(ctx, "IdempotencyService.Begin")
+	defer span.End()
+
+	sum := sha256.Sum256(body)
+	rec := &models.IdempotencyRecord{
+		Key:         key,
+		Method:      method,
+		Path:        path,
+		RequestHash: hex.EncodeToString(sum[:]),
+	}
+
+	reserved, err := repo.ReserveIdempotencyKey(ctx, s.db, rec, s.ttl)
+	if err != nil {
+		return nil, err
+	}
+	if reserved {
+		return nil, nil
+	}
+
+	stored, err := repo.GetIdempotencyRecord(ctx, s.db, key, method, path)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		// Released by a failed request between the two queries.
+		return nil, ErrIdempotencyKeyInProgress
+	}
+	if stored.RequestHash != rec.RequestHash {
+		return nil, ErrIdempotencyKeyReused
+	}
+	if !stored.Completed {
+		return nil, ErrIdempotencyKeyInProgress
+	}
+
+	return stored, nil
+}
+
+func (s *IdempotencyService) Complete(ctx context.Context, rec *models.IdempotencyRecord) error {
+	ctx, span := tracer.Start(ctx, "IdempotencyService.Complete")
+	defer span.End()
+
+	return repo.CompleteIdempotencyKey(ctx, s.db, rec)
+}
+
+// Release forgets the key so that the request can be retried, used when
+// processing failed and the response should not be replayed.
+func (s *IdempotencyService) Release(ctx context.Context, key, method, path string) error {
+	ctx, span := tracer.Start(ctx, "IdempotencyService.Release")
+	defer span.End()
+
+	return repo.DeleteIdempotencyKey(ctx, s.db, key, method, path)
+}
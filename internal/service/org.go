@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+	"github.com/Wucop228/avito-PullRequest/internal/repo"
+)
+
+var (
+	ErrInvalidOrg = errors.New("invalid org file")
+)
+
+type OrgService struct {
+	db    *sql.DB
+	prSvc *PullRequestService
+}
+
+func NewOrgService(db *sql.DB, prSvc *PullRequestService) *OrgService {
+	return &OrgService{db: db, prSvc: prSvc}
+}
+
+type ReconcileOptions struct {
+	// Apply makes the changes; otherwise only the plan is returned.
+	Apply bool
+	// ReassignReviews hands open reviews of users that end up inactive over
+	// to their active teammates.
+	ReassignReviews bool
+}
+
+// Import creates missing teams and creates or updates the listed users.
+// Teams and users absent from the file are left untouched. With dryRun the
+// changes are only computed; otherwise they are applied in one transaction.
+func (s *OrgService) Import(ctx context.Context, teams []models.RequestTeamAdd, dryRun bool) (*models.OrgDiff, error) {
+	ctx, span := tracer.Start(ctx, "OrgService.Import")
+	defer span.End()
+
+	if err := validateOrg(teams); err != nil {
+		return nil, err
+	}
+
+	current, err := repo.GetAllTeamsWithMembers(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &models.OrgDiff{
+		DryRun:  dryRun,
+		Changes: diffOrg(current, teams, false),
+	}
+
+	if dryRun || len(diff.Changes) == 0 {
+		return diff, nil
+	}
+
+	if err := repo.ApplyOrgChanges(ctx, s.db, diff.Changes); err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "org import applied", "changes", len(diff.Changes))
+
+	return diff, nil
+}
+
+// Reconcile makes the database match the desired org: missing teams and users
+// are created, changed users are updated or moved, and users absent from the
+// desired state are deactivated. Teams absent from it are kept, since users
+// and pull requests still reference them.
+func (s *OrgService) Reconcile(ctx context.Context, desired []models.RequestTeamAdd, opts ReconcileOptions) (*models.OrgDiff, error) {
+	ctx, span := tracer.Start(ctx, "OrgService.Reconcile")
+	defer span.End()
+
+	if err := validateOrg(desired); err != nil {
+		return nil, err
+	}
+	if len(desired) == 0 {
+		return nil, fmt.Errorf("%w: desired state has no teams", ErrInvalidOrg)
+	}
+
+	current, err := repo.GetAllTeamsWithMembers(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &models.OrgDiff{
+		DryRun:  !opts.Apply,
+		Changes: diffOrg(current, desired, true),
+	}
+
+	var reassignments []models.OrgReassignment
+	if opts.ReassignReviews {
+		if reassignments, err = s.openReviewsOfDeactivated(ctx, diff.Changes); err != nil {
+			return nil, err
+		}
+	}
+
+	if !opts.Apply {
+		diff.Reassignments = reassignments
+		return diff, nil
+	}
+
+	if len(diff.Changes) > 0 {
+		if err := repo.ApplyOrgChanges(ctx, s.db, diff.Changes); err != nil {
+			return nil, err
+		}
+		slog.InfoContext(ctx, "org reconcile applied", "changes", len(diff.Changes))
+	}
+
+	// Reviews are handed over after the deactivation is committed, so that
+	// the deactivated users are no longer candidates.
+	for i := range reassignments {
+		r := &reassignments[i]
+		_, newUserID, err := s.prSvc.ReassignReviewer(ctx, r.PullRequestID, r.OldUserID)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to hand over review of deactivated user",
+				"pull_request_id", r.PullRequestID,
+				"user_id", r.OldUserID,
+				"error", err,
+			)
+			r.Error = err.Error()
+			continue
+		}
+		r.NewUserID = newUserID
+	}
+	diff.Reassignments = reassignments
+
+	return diff, nil
+}
+
+func (s *OrgService) openReviewsOfDeactivated(ctx context.Context, changes []models.OrgChange) ([]models.OrgReassignment, error) {
+	reassignments := make([]models.OrgReassignment, 0)
+
+	for _, change := range changes {
+		if change.Action == models.OrgChangeCreateUser || change.Action == models.OrgChangeCreateTeam || change.IsActive {
+			continue
+		}
+
+		prs, err := repo.GetPullRequestsByReviewer(ctx, s.db, change.UserID)
+		if err != nil {
+			return nil, err
+		}
+		for _, pr := range prs {
+			if pr.Status != "OPEN" {
+				continue
+			}
+			reassignments = append(reassignments, models.OrgReassignment{
+				PullRequestID: pr.PullRequestID,
+				OldUserID:     change.UserID,
+			})
+		}
+	}
+
+	return reassignments, nil
+}
+
+func (s *OrgService) Export(ctx context.Context) ([]models.RequestTeamAdd, error) {
+	ctx, span := tracer.Start(ctx, "OrgService.Export")
+	defer span.End()
+
+	return repo.GetAllTeamsWithMembers(ctx, s.db)
+}
+
+func validateOrg(teams []models.RequestTeamAdd) error {
+	teamNames := make(map[string]struct{})
+	userTeams := make(map[string]string)
+
+	for _, team := range teams {
+		if team.TeamName == "" {
+			return fmt.Errorf("%w: team_name is required", ErrInvalidOrg)
+		}
+		if _, ok := teamNames[team.TeamName]; ok {
+			return fmt.Errorf("%w: team %s is listed twice", ErrInvalidOrg, team.TeamName)
+		}
+		teamNames[team.TeamName] = struct{}{}
+
+		for _, m := range team.Members {
+			if m.UserID == "" || m.Username == "" {
+				return fmt.Errorf("%w: team %s: user_id and username are required", ErrInvalidOrg, team.TeamName)
+			}
+			if other, ok := userTeams[m.UserID]; ok {
+				return fmt.Errorf("%w: user %s is listed in %s and %s", ErrInvalidOrg, m.UserID, other, team.TeamName)
+			}
+			userTeams[m.UserID] = team.TeamName
+		}
+	}
+
+	return nil
+}
+
+// diffOrg lists the changes that bring current to desired, teams first so
+// that users can reference them. With prune, active users missing from
+// desired are deactivated.
+func diffOrg(current, desired []models.RequestTeamAdd, prune bool) []models.OrgChange {
+	existingTeams := make(map[string]struct{}, len(current))
+	existingUsers := make(map[string]models.User)
+	for _, team := range current {
+		existingTeams[team.TeamName] = struct{}{}
+		for _, m := range team.Members {
+			existingUsers[m.UserID] = models.User{
+				UserID:   m.UserID,
+				Username: m.Username,
+				TeamName: team.TeamName,
+				IsActive: m.IsActive,
+			}
+		}
+	}
+
+	changes := make([]models.OrgChange, 0)
+	for _, team := range desired {
+		if _, ok := existingTeams[team.TeamName]; !ok {
+			changes = append(changes, models.OrgChange{
+				Action:   models.OrgChangeCreateTeam,
+				TeamName: team.TeamName,
+			})
+		}
+	}
+
+	listed := make(map[string]struct{})
+	for _, team := range desired {
+		for _, m := range team.Members {
+			listed[m.UserID] = struct{}{}
+
+			change := models.OrgChange{
+				TeamName: team.TeamName,
+				UserID:   m.UserID,
+				Username: m.Username,
+				IsActive: m.IsActive,
+			}
+
+			old, ok := existingUsers[m.UserID]
+			if !ok {
+				change.Action = models.OrgChangeCreateUser
+				changes = append(changes, change)
+				continue
+			}
+
+			change.Details = userChangeDetails(old, change)
+			if len(change.Details) == 0 {
+				continue
+			}
+			change.Action = models.OrgChangeUpdateUser
+			if old.TeamName != change.TeamName {
+				change.Action = models.OrgChangeMoveUser
+			}
+			changes = append(changes, change)
+		}
+	}
+
+	if !prune {
+		return changes
+	}
+
+	for _, team := range current {
+		for _, m := range team.Members {
+			if _, ok := listed[m.UserID]; ok || !m.IsActive {
+				continue
+			}
+			changes = append(changes, models.OrgChange{
+				Action:   models.OrgChangeDeactivateUser,
+				TeamName: team.TeamName,
+				UserID:   m.UserID,
+				Username: m.Username,
+				IsActive: false,
+				Details:  []string{"is_active: true -> false"},
+			})
+		}
+	}
+
+	return changes
+}
+
+func userChangeDetails(old models.User, change models.OrgChange) []string {
+	details := make([]string, 0)
+	if old.TeamName != change.TeamName {
+		details = append(details, fmt.Sprintf("team_name: %s -> %s", old.TeamName, change.TeamName))
+	}
+	if old.Username != change.Username {
+		details = append(details, fmt.Sprintf("username: %s -> %s", old.Username, change.Username))
+	}
+	if old.IsActive != change.IsActive {
+		details = append(details, fmt.Sprintf("is_active: %t -> %t", old.IsActive, change.IsActive))
+	}
+	return details
+}
@@ -1,11 +1,14 @@
 package service
 
 import (
+	"context"
 	"database/sql"
 	"errors"
-	"math/rand"
-	"time"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
 
+	"github.com/Wucop228/avito-PullRequest/internal/events"
 	"github.com/Wucop228/avito-PullRequest/internal/models"
 	"github.com/Wucop228/avito-PullRequest/internal/repo"
 )
@@ -13,58 +16,144 @@ import (
 var (
 	ErrPRExists            = errors.New("PR id already exists")
 	ErrPRNotFound          = errors.New("pull request not found")
-	ErrPRMerged            = errors.New("cannot reassign on merged PR")
-	ErrReviewerNotAssigned = errors.New("reviewer is not assigned to this PR")
+	ErrPRMerged            = repo.ErrPullRequestMerged
+	ErrPRAlreadyMerged     = errors.New("pull request is already merged")
+	ErrReviewerNotAssigned = repo.ErrReviewerNotAssigned
 	ErrNoCandidate         = errors.New("no active replacement candidate in team")
 	ErrAuthorNotFound      = errors.New("author not found")
 )
 
+type AssignmentConfig struct {
+	// Reviewers is how many reviewers a new pull request gets.
+	Reviewers int
+	// Seed, when set, makes reviewer selection deterministic: the same
+	// seed, PR and candidates always give the same reviewers.
+	Seed string
+	// Rand is the random source used without Seed. Nil uses a randomly
+	// seeded one; tests can pass a fixed one.
+	Rand rand.Source
+	// ReplacementPool is where replacements for reviewers come from. Empty
+	// means ReplacementPoolReviewerTeam.
+	ReplacementPool ReplacementPool
+	// DeclineQuota is how many reviews a user may decline per month unless
+	// their team sets its own quota.
+	DeclineQuota int
+}
+
 type PullRequestService struct {
-	db *sql.DB
+	db        *sql.DB
+	sink      events.Sink
+	cfg       AssignmentConfig
+	selection *selection
+}
+
+// NewPullRequestService publishes PR and reviewer events to sink.
+func NewPullRequestService(db *sql.DB, sink events.Sink, cfg AssignmentConfig) *PullRequestService {
+	return &PullRequestService{
+		db:        db,
+		sink:      sink,
+		cfg:       cfg,
+		selection: newSelection(cfg.Seed, cfg.Rand),
+	}
+}
+
+func (s *PullRequestService) CreatePullRequest(ctx context.Context, req *models.RequestPullRequestCreate) (*models.PullRequest, error) {
+	ctx, span := tracer.Start(ctx, "PullRequestService.CreatePullRequest")
+	defer span.End()
+
+	pr, _, err := s.createPullRequest(ctx, req)
+	return pr, err
 }
 
-func NewPullRequestService(db *sql.DB) *PullRequestService {
-	rand.Seed(time.Now().UnixNano())
-	return &PullRequestService{db: db}
+// CreatePullRequestWithExplanation is CreatePullRequest that also returns how
+// the reviewers were picked.
+func (s *PullRequestService) CreatePullRequestWithExplanation(ctx context.Context, req *models.RequestPullRequestCreate) (*models.PullRequest, *models.AssignmentExplanation, error) {
+	ctx, span := tracer.Start(ctx, "PullRequestService.CreatePullRequestWithExplanation")
+	defer span.End()
+
+	return s.createPullRequest(ctx, req)
 }
 
-func (s *PullRequestService) CreatePullRequest(req *models.RequestPullRequestCreate) (*models.PullRequest, error) {
-	existing, err := repo.GetPullRequestWithReviewers(s.db, req.PullRequestID)
+func (s *PullRequestService) createPullRequest(ctx context.Context, req *models.RequestPullRequestCreate) (*models.PullRequest, *models.AssignmentExplanation, error) {
+	existing, err := repo.GetPullRequestWithReviewers(ctx, s.db, req.PullRequestID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if existing != nil {
-		return nil, ErrPRExists
+		return nil, nil, ErrPRExists
 	}
 
-	author, err := repo.GetUserByID(s.db, req.AuthorID)
+	author, err := repo.GetUserByID(ctx, s.db, req.AuthorID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if author == nil {
-		return nil, ErrAuthorNotFound
+		return nil, nil, ErrAuthorNotFound
 	}
 
-	teamUsers, err := repo.GetActiveUsersByTeam(s.db, author.TeamName)
+	teamUsers, err := repo.GetUsersByTeams(ctx, s.db, []string{author.TeamName})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	candidateIDs := make([]string, 0)
-	for _, u := range teamUsers {
-		if u.UserID == author.UserID {
-			continue
+	candidateIDs, excluded := splitCandidates(teamUsers, func(u models.User) string {
+		switch {
+		case u.UserID == author.UserID:
+			return models.ExcludedAuthor
+		case !u.IsActive:
+			return models.ExcludedInactive
 		}
-		candidateIDs = append(candidateIDs, u.UserID)
+		return ""
+	})
+
+	var owners []string
+	if len(req.ChangedFiles) > 0 {
+		rules, err := repo.GetTeamCodeOwners(ctx, s.db, author.TeamName)
+		if err != nil {
+			return nil, nil, err
+		}
+		owners = codeOwnersFor(rules, req.ChangedFiles)
 	}
 
-	selected := selectRandomReviewers(candidateIDs, 2)
+	subject := []string{"create", req.PullRequestID}
+	r, rosterVersion := s.selection.rand(subject, candidateIDs)
+	selected := selectReviewersPreferring(r, candidateIDs, owners, s.cfg.Reviewers)
+
+	explanation := s.explain(models.AssignmentExplanation{
+		Action:        "create",
+		TeamName:      author.TeamName,
+		Candidates:    candidateIDs,
+		Excluded:      excluded,
+		Preferred:     preferredCandidates(candidateIDs, owners),
+		Requested:     s.cfg.Reviewers,
+		Subject:       subject,
+		RosterVersion: rosterVersion,
+		Selected:      selected,
+	})
 
-	return repo.CreatePullRequest(s.db, req, selected)
+	pr, err := repo.CreatePullRequest(ctx, s.db, req, selected, explanation)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	slog.InfoContext(ctx, "pull request created",
+		"pull_request_id", pr.PullRequestID,
+		"author_id", pr.AuthorID,
+		"reviewers", selected,
+		"roster_version", rosterVersion,
+	)
+
+	s.publish(ctx, events.New(events.TypePullRequestCreated, author.TeamName, prUserIDs(pr), pr))
+	s.publishAssigned(ctx, author.TeamName, pr, selected)
+
+	return pr, explanation, nil
 }
 
-func (s *PullRequestService) MergePullRequest(prID string) (*models.PullRequest, error) {
-	pr, err := repo.GetPullRequestWithReviewers(s.db, prID)
+func (s *PullRequestService) MergePullRequest(ctx context.Context, prID string) (*models.PullRequest, error) {
+	ctx, span := tracer.Start(ctx, "PullRequestService.MergePullRequest")
+	defer span.End()
+
+	pr, err := repo.GetPullRequestWithReviewers(ctx, s.db, prID)
 	if err != nil {
 		return nil, err
 	}
@@ -76,7 +165,7 @@ func (s *PullRequestService) MergePullRequest(prID string) (*models.PullRequest,
 		return pr, nil
 	}
 
-	mergedAt, err := repo.MarkPullRequestMerged(s.db, prID)
+	mergedAt, err := repo.MarkPullRequestMerged(ctx, s.db, prID)
 	if err != nil {
 		return nil, err
 	}
@@ -84,69 +173,169 @@ func (s *PullRequestService) MergePullRequest(prID string) (*models.PullRequest,
 	pr.Status = "MERGED"
 	pr.MergedAt = mergedAt
 
+	slog.InfoContext(ctx, "pull request merged", "pull_request_id", prID)
+
+	s.publish(ctx, events.New(events.TypePullRequestMerged, s.authorTeam(ctx, pr), prUserIDs(pr), pr))
+
 	return pr, nil
 }
 
-func (s *PullRequestService) ReassignReviewer(prID, oldUserID string) (*models.PullRequest, string, error) {
-	pr, err := repo.GetPullRequestWithReviewers(s.db, prID)
+// ClosePullRequest marks an open PR as CLOSED without merging it. Closing an
+// already closed PR is a no-op.
+func (s *PullRequestService) ClosePullRequest(ctx context.Context, prID string) (*models.PullRequest, error) {
+	ctx, span := tracer.Start(ctx, "PullRequestService.ClosePullRequest")
+	defer span.End()
+
+	return s.setStatus(ctx, prID, "CLOSED")
+}
+
+// ReopenPullRequest moves a CLOSED PR back to OPEN. Reopening an open PR is a
+// no-op.
+func (s *PullRequestService) ReopenPullRequest(ctx context.Context, prID string) (*models.PullRequest, error) {
+	ctx, span := tracer.Start(ctx, "PullRequestService.ReopenPullRequest")
+	defer span.End()
+
+	return s.setStatus(ctx, prID, "OPEN")
+}
+
+func (s *PullRequestService) setStatus(ctx context.Context, prID, status string) (*models.PullRequest, error) {
+	pr, err := repo.GetPullRequestWithReviewers(ctx, s.db, prID)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
 	if pr == nil {
-		return nil, "", ErrPRNotFound
+		return nil, ErrPRNotFound
 	}
 
+	if pr.Status == status {
+		return pr, nil
+	}
 	if pr.Status == "MERGED" {
-		return nil, "", ErrPRMerged
+		return nil, ErrPRAlreadyMerged
 	}
 
-	assigned := false
-	for _, id := range pr.AssignedReviewers {
-		if id == oldUserID {
-			assigned = true
-			break
-		}
-	}
-	if !assigned {
-		return nil, "", ErrReviewerNotAssigned
+	if err := repo.SetPullRequestStatus(ctx, s.db, prID, status); err != nil {
+		return nil, err
 	}
 
-	user, err := repo.GetUserByID(s.db, oldUserID)
+	pr.Status = status
+
+	slog.InfoContext(ctx, "pull request status changed", "pull_request_id", prID, "status", status)
+
+	return pr, nil
+}
+
+func (s *PullRequestService) ReassignReviewer(ctx context.Context, prID, oldUserID string) (*models.PullRequest, string, error) {
+	return s.ReassignReviewerWithReason(ctx, prID, oldUserID, "")
+}
+
+// ReassignReviewerWithReason is ReassignReviewer for automatic replacements;
+// the reason ends up in the published event.
+func (s *PullRequestService) ReassignReviewerWithReason(ctx context.Context, prID, oldUserID, reason string) (*models.PullRequest, string, error) {
+	ctx, span := tracer.Start(ctx, "PullRequestService.ReassignReviewerWithReason")
+	defer span.End()
+
+	pr, newReviewerID, _, err := s.reassignReviewer(ctx, prID, oldUserID, reason, nil)
+	return pr, newReviewerID, err
+}
+
+// ReassignReviewerWithExplanation is ReassignReviewer that also returns how
+// the new reviewer was picked.
+func (s *PullRequestService) ReassignReviewerWithExplanation(ctx context.Context, prID, oldUserID string) (*models.PullRequest, string, *models.AssignmentExplanation, error) {
+	ctx, span := tracer.Start(ctx, "PullRequestService.ReassignReviewerWithExplanation")
+	defer span.End()
+
+	return s.reassignReviewer(ctx, prID, oldUserID, "", nil)
+}
+
+// reassignReviewer replaces oldUserID with a random candidate. With decline,
+// the replacement is recorded as the reviewer declining the review, and only
+// while they are within the quota.
+func (s *PullRequestService) reassignReviewer(ctx context.Context, prID, oldUserID, reason string, decline *reviewDecline) (*models.PullRequest, string, *models.AssignmentExplanation, error) {
+	pr, err := repo.GetPullRequestWithReviewers(ctx, s.db, prID)
 	if err != nil {
-		return nil, "", err
+		return nil, "", nil, err
 	}
-	if user == nil {
-		return nil, "", ErrUserNotFound
+	if pr == nil {
+		return nil, "", nil, ErrPRNotFound
 	}
 
-	teamUsers, err := repo.GetActiveUsersByTeam(s.db, user.TeamName)
-	if err != nil {
-		return nil, "", err
+	switch pr.Status {
+	case "OPEN":
+	case "MERGED":
+		return nil, "", nil, ErrPRMerged
+	default:
+		return nil, "", nil, ErrPRNotOpen
 	}
 
-	exclude := make(map[string]struct{})
-	exclude[oldUserID] = struct{}{}
-	exclude[pr.AuthorID] = struct{}{}
-	for _, id := range pr.AssignedReviewers {
-		exclude[id] = struct{}{}
+	if !slices.Contains(pr.AssignedReviewers, oldUserID) {
+		return nil, "", nil, ErrReviewerNotAssigned
 	}
 
-	candidates := make([]string, 0)
-	for _, u := range teamUsers {
-		if _, ok := exclude[u.UserID]; ok {
-			continue
+	user, err := repo.GetUserByID(ctx, s.db, oldUserID)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if user == nil {
+		return nil, "", nil, ErrUserNotFound
+	}
+
+	pool := s.replacementPool()
+	authorTeam := ""
+	if pool.needsAuthor() {
+		author, err := repo.GetUserByID(ctx, s.db, pr.AuthorID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		if author == nil {
+			return nil, "", nil, ErrAuthorNotFound
 		}
-		candidates = append(candidates, u.UserID)
+		authorTeam = author.TeamName
 	}
+	teams := pool.teams(user.TeamName, authorTeam)
+
+	teamUsers, err := repo.GetUsersByTeams(ctx, s.db, teams)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	candidates, excluded := replacementCandidates(pr, oldUserID, teams, teamUsers)
 
 	if len(candidates) == 0 {
-		return nil, "", ErrNoCandidate
+		return nil, "", nil, ErrNoCandidate
 	}
 
-	newReviewerID := candidates[rand.Intn(len(candidates))]
+	// Sorted so that a seeded choice does not depend on the query order.
+	slices.Sort(candidates)
+	subject := []string{"reassign", prID, oldUserID}
+	r, rosterVersion := s.selection.rand(subject, candidates)
+	newReviewerID := candidates[r.IntN(len(candidates))]
+
+	explanation := s.explain(models.AssignmentExplanation{
+		Action:         "reassign",
+		TeamName:       teams[0],
+		Pool:           string(pool),
+		Teams:          teams,
+		ReplacedUserID: oldUserID,
+		Candidates:     candidates,
+		Excluded:       excluded,
+		Requested:      1,
+		Subject:        subject,
+		RosterVersion:  rosterVersion,
+		Selected:       []string{newReviewerID},
+	})
 
-	if err := repo.ReplacePullRequestReviewer(s.db, prID, oldUserID, newReviewerID); err != nil {
-		return nil, "", err
+	if decline != nil {
+		declined, ok, err := repo.DeclinePullRequestReview(ctx, s.db, prID, oldUserID, newReviewerID, decline.reason, decline.quota, decline.since, explanation)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		if !ok {
+			return nil, "", nil, ErrDeclineQuotaExceeded
+		}
+		decline.declined = declined
+	} else if err := repo.ReplacePullRequestReviewer(ctx, s.db, prID, oldUserID, newReviewerID, explanation); err != nil {
+		return nil, "", nil, err
 	}
 
 	for i, id := range pr.AssignedReviewers {
@@ -156,29 +345,171 @@ func (s *PullRequestService) ReassignReviewer(prID, oldUserID string) (*models.P
 		}
 	}
 
-	return pr, newReviewerID, nil
+	slog.InfoContext(ctx, "reviewer reassigned",
+		"pull_request_id", prID,
+		"old_user_id", oldUserID,
+		"new_user_id", newReviewerID,
+		"reason", reason,
+		"pool", pool,
+		"roster_version", rosterVersion,
+	)
+
+	payload := reviewerReassignedPayload{
+		PR:         pr,
+		OldUserID:  oldUserID,
+		ReplacedBy: newReviewerID,
+		Reason:     reason,
+	}
+	if decline != nil {
+		payload.DeclineReason = decline.reason
+	}
+	s.publish(ctx, events.New(events.TypeReviewerReassigned, s.authorTeam(ctx, pr), []string{oldUserID, newReviewerID}, payload))
+
+	return pr, newReviewerID, explanation, nil
 }
 
-func (s *PullRequestService) GetUserReviews(userID string) ([]models.PullRequestShort, error) {
-	return repo.GetPullRequestsByReviewer(s.db, userID)
+// GetPullRequest returns the PR with its reviewers and, with explain, the
+// explanations of every assignment made for it, oldest first.
+func (s *PullRequestService) GetPullRequest(ctx context.Context, prID string, explain bool) (*models.PullRequest, []models.AssignmentExplanation, error) {
+	ctx, span := tracer.Start(ctx, "PullRequestService.GetPullRequest")
+	defer span.End()
+
+	pr, err := repo.GetPullRequestWithReviewers(ctx, s.db, prID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if pr == nil {
+		return nil, nil, ErrPRNotFound
+	}
+	if !explain {
+		return pr, nil, nil
+	}
+
+	explanations, err := repo.GetAssignmentExplanations(ctx, s.db, prID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pr, explanations, nil
+}
+
+func (s *PullRequestService) GetUserReviews(ctx context.Context, userID string) ([]models.PullRequestShort, error) {
+	ctx, span := tracer.Start(ctx, "PullRequestService.GetUserReviews")
+	defer span.End()
+
+	return repo.GetPullRequestsByReviewer(ctx, s.db, userID)
+}
+
+type reviewerAssignedPayload struct {
+	PullRequestID string `json:"pull_request_id"`
+	ReviewerID    string `json:"reviewer_id"`
 }
 
-func selectRandomReviewers(ids []string, maxCount int) []string {
+type reviewerReassignedPayload struct {
+	PR         *models.PullRequest `json:"pr"`
+	OldUserID  string              `json:"old_user_id"`
+	ReplacedBy string              `json:"replaced_by"`
+	Reason     string              `json:"reason,omitempty"`
+	// DeclineReason is what the old reviewer said when declining.
+	DeclineReason string `json:"decline_reason,omitempty"`
+}
+
+// publish happens after the change is committed, so a failing sink is only
+// logged. The event outlives the request: a client that disconnects right
+// after the change must not cancel its delivery.
+func (s *PullRequestService) publish(ctx context.Context, event events.Event) {
+	if err := s.sink.Publish(context.WithoutCancel(ctx), event); err != nil {
+		slog.ErrorContext(ctx, "failed to publish event", "type", event.Type, "error", err)
+	}
+}
+
+func (s *PullRequestService) authorTeam(ctx context.Context, pr *models.PullRequest) string {
+	author, err := repo.GetUserByID(ctx, s.db, pr.AuthorID)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to look up author team", "pull_request_id", pr.PullRequestID, "error", err)
+		return ""
+	}
+	if author == nil {
+		return ""
+	}
+	return author.TeamName
+}
+
+func (s *PullRequestService) replacementPool() ReplacementPool {
+	if s.cfg.ReplacementPool == "" {
+		return ReplacementPoolReviewerTeam
+	}
+	return s.cfg.ReplacementPool
+}
+
+// explain completes an explanation with the selection strategy. The subject
+// only matters for a seeded selection and is dropped otherwise.
+func (s *PullRequestService) explain(e models.AssignmentExplanation) *models.AssignmentExplanation {
+	e.Strategy = s.selection.strategy()
+	if s.cfg.Seed == "" {
+		e.Subject = nil
+	}
+	return &e
+}
+
+// preferredCandidates lists the candidates that are among the code owners.
+func preferredCandidates(candidates, owners []string) []string {
+	preferred := make([]string, 0)
+	for _, id := range candidates {
+		if slices.Contains(owners, id) {
+			preferred = append(preferred, id)
+		}
+	}
+	return preferred
+}
+
+// prUserIDs lists the author and the reviewers of pr.
+func prUserIDs(pr *models.PullRequest) []string {
+	return append([]string{pr.AuthorID}, pr.AssignedReviewers...)
+}
+
+func selectRandomReviewers(r *rand.Rand, ids []string, maxCount int) []string {
 	n := len(ids)
 	if n == 0 || maxCount <= 0 {
 		return []string{}
 	}
-	if n <= maxCount {
-		res := make([]string, n)
-		copy(res, ids)
-		return res
-	}
 
+	// Sorted so that a seeded choice does not depend on the query order.
 	copyIDs := make([]string, n)
 	copy(copyIDs, ids)
-	rand.Shuffle(n, func(i, j int) {
+	slices.Sort(copyIDs)
+	if n <= maxCount {
+		return copyIDs
+	}
+
+	r.Shuffle(n, func(i, j int) {
 		copyIDs[i], copyIDs[j] = copyIDs[j], copyIDs[i]
 	})
 
 	return copyIDs[:maxCount]
 }
+
+// selectReviewersPreferring fills as many slots as possible from the preferred
+// candidates and the rest from the remaining ones, randomly within each group.
+func selectReviewersPreferring(r *rand.Rand, ids, preferred []string, maxCount int) []string {
+	if len(preferred) == 0 {
+		return selectRandomReviewers(r, ids, maxCount)
+	}
+
+	isPreferred := make(map[string]struct{}, len(preferred))
+	for _, id := range preferred {
+		isPreferred[id] = struct{}{}
+	}
+
+	first := make([]string, 0)
+	rest := make([]string, 0)
+	for _, id := range ids {
+		if _, ok := isPreferred[id]; ok {
+			first = append(first, id)
+		} else {
+			rest = append(rest, id)
+		}
+	}
+
+	selected := selectRandomReviewers(r, first, maxCount)
+	return append(selected, selectRandomReviewers(r, rest, maxCount-len(selected))...)
+}
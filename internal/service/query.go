@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+	"github.com/Wucop228/avito-PullRequest/internal/repo"
+)
+
+// MaxPullRequestsPage caps ListPullRequests.
+const MaxPullRequestsPage = 500
+
+// QueryService provides the batched reads behind the GraphQL API. Every
+// method takes many keys and returns the results keyed by them; keys with
+// nothing found are absent from the map.
+type QueryService struct {
+	db *sql.DB
+}
+
+func NewQueryService(db *sql.DB) *QueryService {
+	return &QueryService{db: db}
+}
+
+func (s *QueryService) ListTeamNames(ctx context.Context) ([]string, error) {
+	ctx, span := tracer.Start(ctx, "QueryService.ListTeamNames")
+	defer span.End()
+
+	return repo.GetTeamNames(ctx, s.db)
+}
+
+func (s *QueryService) TeamsExist(ctx context.Context, names []string) (map[string]bool, error) {
+	ctx, span := tracer.Start(ctx, "QueryService.TeamsExist")
+	defer span.End()
+
+	found, err := repo.GetExistingTeamNames(ctx, s.db, names)
+	if err != nil {
+		return nil, err
+	}
+
+	exists := make(map[string]bool, len(found))
+	for _, name := range found {
+		exists[name] = true
+	}
+	return exists, nil
+}
+
+func (s *QueryService) UsersByID(ctx context.Context, ids []string) (map[string]models.User, error) {
+	ctx, span := tracer.Start(ctx, "QueryService.UsersByID")
+	defer span.End()
+
+	users, err := repo.GetUsersByIDs(ctx, s.db, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]models.User, len(users))
+	for _, u := range users {
+		byID[u.UserID] = u
+	}
+	return byID, nil
+}
+
+func (s *QueryService) MembersByTeam(ctx context.Context, teamNames []string) (map[string][]models.User, error) {
+	ctx, span := tracer.Start(ctx, "QueryService.MembersByTeam")
+	defer span.End()
+
+	users, err := repo.GetUsersByTeams(ctx, s.db, teamNames)
+	if err != nil {
+		return nil, err
+	}
+
+	byTeam := make(map[string][]models.User)
+	for _, u := range users {
+		byTeam[u.TeamName] = append(byTeam[u.TeamName], u)
+	}
+	return byTeam, nil
+}
+
+func (s *QueryService) PullRequestsByID(ctx context.Context, ids []string) (map[string]models.PullRequest, error) {
+	ctx, span := tracer.Start(ctx, "QueryService.PullRequestsByID")
+	defer span.End()
+
+	prs, err := repo.GetPullRequestsByIDs(ctx, s.db, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]models.PullRequest, len(prs))
+	for _, pr := range prs {
+		byID[pr.PullRequestID] = pr
+	}
+	return byID, nil
+}
+
+// ListPullRequests returns up to limit PRs, newest first, optionally with the
+// given status and author. limit is clamped to MaxPullRequestsPage.
+func (s *QueryService) ListPullRequests(ctx context.Context, status, authorID string, limit int) ([]models.PullRequest, error) {
+	ctx, span := tracer.Start(ctx, "QueryService.ListPullRequests")
+	defer span.End()
+
+	if limit <= 0 || limit > MaxPullRequestsPage {
+		limit = MaxPullRequestsPage
+	}
+	return repo.ListPullRequests(ctx, s.db, status, authorID, limit)
+}
+
+func (s *QueryService) PullRequestsByAuthor(ctx context.Context, authorIDs []string) (map[string][]models.PullRequest, error) {
+	ctx, span := tracer.Start(ctx, "QueryService.PullRequestsByAuthor")
+	defer span.End()
+
+	return repo.GetPullRequestsByAuthors(ctx, s.db, authorIDs)
+}
+
+func (s *QueryService) PullRequestsByReviewer(ctx context.Context, reviewerIDs []string) (map[string][]models.PullRequest, error) {
+	ctx, span := tracer.Start(ctx, "QueryService.PullRequestsByReviewer")
+	defer span.End()
+
+	return repo.GetPullRequestsByReviewers(ctx, s.db, reviewerIDs)
+}
+
+func (s *QueryService) ReviewersByPullRequest(ctx context.Context, prIDs []string) (map[string][]models.User, error) {
+	ctx, span := tracer.Start(ctx, "QueryService.ReviewersByPullRequest")
+	defer span.End()
+
+	return repo.GetReviewersByPullRequests(ctx, s.db, prIDs)
+}
+
+func (s *QueryService) AssignmentsByPullRequest(ctx context.Context, prIDs []string) (map[string][]models.ReviewAssignmentRecord, error) {
+	ctx, span := tracer.Start(ctx, "QueryService.AssignmentsByPullRequest")
+	defer span.End()
+
+	return repo.GetReviewAssignmentsByPullRequests(ctx, s.db, prIDs)
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"slices"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+)
+
+// ReplacementPool says which teams a replacement for a reviewer is picked
+// from. It matters once reviewers from other teams are assigned, for example
+// by hand.
+type ReplacementPool string
+
+const (
+	// ReplacementPoolReviewerTeam picks from the team of the replaced
+	// reviewer. It is the default.
+	ReplacementPoolReviewerTeam ReplacementPool = "reviewer_team"
+	// ReplacementPoolAuthorTeam picks from the team of the PR author, like
+	// CreatePullRequest does.
+	ReplacementPoolAuthorTeam ReplacementPool = "author_team"
+	// ReplacementPoolUnion picks from both teams.
+	ReplacementPoolUnion ReplacementPool = "union"
+)
+
+// teams lists the teams of the pool, the replaced reviewer's first.
+func (p ReplacementPool) teams(reviewerTeam, authorTeam string) []string {
+	switch p {
+	case ReplacementPoolAuthorTeam:
+		return []string{authorTeam}
+	case ReplacementPoolUnion:
+		if authorTeam == reviewerTeam {
+			return []string{reviewerTeam}
+		}
+		return []string{reviewerTeam, authorTeam}
+	}
+	return []string{reviewerTeam}
+}
+
+// needsAuthor reports whether the pool depends on the author's team.
+func (p ReplacementPool) needsAuthor() bool {
+	return p == ReplacementPoolAuthorTeam || p == ReplacementPoolUnion
+}
+
+// replacementCandidates splits the users of teams into those that can replace
+// oldUserID on pr and those that cannot. Users of other teams are ignored.
+func replacementCandidates(pr *models.PullRequest, oldUserID string, teams []string, users []models.User) ([]string, []models.ExcludedCandidate) {
+	members := make([]models.User, 0, len(users))
+	for _, u := range users {
+		if slices.Contains(teams, u.TeamName) {
+			members = append(members, u)
+		}
+	}
+
+	return splitCandidates(members, func(u models.User) string {
+		switch {
+		case u.UserID == oldUserID:
+			return models.ExcludedReplaced
+		case u.UserID == pr.AuthorID:
+			return models.ExcludedAuthor
+		case slices.Contains(pr.AssignedReviewers, u.UserID):
+			return models.ExcludedAlreadyAssigned
+		case !u.IsActive:
+			return models.ExcludedInactive
+		}
+		return ""
+	})
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+)
+
+// poolRoster has a backend author whose PR got a frontend reviewer by hand,
+// which is where the policies start to differ.
+var poolRoster = []models.User{
+	{UserID: "b1", TeamName: "backend", IsActive: true},
+	{UserID: "b2", TeamName: "backend", IsActive: true},
+	{UserID: "b3", TeamName: "backend", IsActive: true},
+	{UserID: "b4", TeamName: "backend", IsActive: false},
+	{UserID: "f1", TeamName: "frontend", IsActive: true},
+	{UserID: "f2", TeamName: "frontend", IsActive: true},
+	{UserID: "f3", TeamName: "frontend", IsActive: false},
+	{UserID: "o1", TeamName: "ops", IsActive: true},
+}
+
+var poolPR = &models.PullRequest{
+	PullRequestID:     "pr-1",
+	AuthorID:          "b1",
+	Status:            "OPEN",
+	AssignedReviewers: []string{"b2", "f1"},
+}
+
+func TestReplacementPoolCandidates(t *testing.T) {
+	tests := []struct {
+		name          string
+		pool          ReplacementPool
+		oldUserID     string
+		wantTeams     []string
+		wantCandidate []string
+		wantExcluded  map[string]string
+	}{
+		{
+			name:          "reviewer team, cross-team reviewer",
+			pool:          ReplacementPoolReviewerTeam,
+			oldUserID:     "f1",
+			wantTeams:     []string{"frontend"},
+			wantCandidate: []string{"f2"},
+			wantExcluded: map[string]string{
+				"f1": models.ExcludedReplaced,
+				"f3": models.ExcludedInactive,
+			},
+		},
+		{
+			name:          "author team, cross-team reviewer",
+			pool:          ReplacementPoolAuthorTeam,
+			oldUserID:     "f1",
+			wantTeams:     []string{"backend"},
+			wantCandidate: []string{"b3"},
+			wantExcluded: map[string]string{
+				"b1": models.ExcludedAuthor,
+				"b2": models.ExcludedAlreadyAssigned,
+				"b4": models.ExcludedInactive,
+			},
+		},
+		{
+			name:          "union, cross-team reviewer",
+			pool:          ReplacementPoolUnion,
+			oldUserID:     "f1",
+			wantTeams:     []string{"frontend", "backend"},
+			wantCandidate: []string{"b3", "f2"},
+			wantExcluded: map[string]string{
+				"b1": models.ExcludedAuthor,
+				"b2": models.ExcludedAlreadyAssigned,
+				"b4": models.ExcludedInactive,
+				"f1": models.ExcludedReplaced,
+				"f3": models.ExcludedInactive,
+			},
+		},
+		{
+			name:          "reviewer team, same-team reviewer",
+			pool:          ReplacementPoolReviewerTeam,
+			oldUserID:     "b2",
+			wantTeams:     []string{"backend"},
+			wantCandidate: []string{"b3"},
+			wantExcluded: map[string]string{
+				"b1": models.ExcludedAuthor,
+				"b2": models.ExcludedReplaced,
+				"b4": models.ExcludedInactive,
+			},
+		},
+		{
+			name:          "union, same-team reviewer",
+			pool:          ReplacementPoolUnion,
+			oldUserID:     "b2",
+			wantTeams:     []string{"backend"},
+			wantCandidate: []string{"b3"},
+			wantExcluded: map[string]string{
+				"b1": models.ExcludedAuthor,
+				"b2": models.ExcludedReplaced,
+				"b4": models.ExcludedInactive,
+			},
+		},
+		{
+			name:          "empty policy is the reviewer team",
+			pool:          "",
+			oldUserID:     "f1",
+			wantTeams:     []string{"frontend"},
+			wantCandidate: []string{"f2"},
+			wantExcluded: map[string]string{
+				"f1": models.ExcludedReplaced,
+				"f3": models.ExcludedInactive,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reviewer := rosterUser(t, tt.oldUserID)
+			author := rosterUser(t, poolPR.AuthorID)
+
+			teams := tt.pool.teams(reviewer.TeamName, author.TeamName)
+			if !slices.Equal(teams, tt.wantTeams) {
+				t.Fatalf("teams = %v, want %v", teams, tt.wantTeams)
+			}
+
+			candidates, excluded := replacementCandidates(poolPR, tt.oldUserID, teams, poolRoster)
+			slices.Sort(candidates)
+			if !slices.Equal(candidates, tt.wantCandidate) {
+				t.Errorf("candidates = %v, want %v", candidates, tt.wantCandidate)
+			}
+
+			gotExcluded := make(map[string]string, len(excluded))
+			for _, e := range excluded {
+				gotExcluded[e.UserID] = e.Reason
+			}
+			if len(gotExcluded) != len(tt.wantExcluded) {
+				t.Errorf("excluded = %v, want %v", gotExcluded, tt.wantExcluded)
+			}
+			for id, reason := range tt.wantExcluded {
+				if gotExcluded[id] != reason {
+					t.Errorf("excluded[%s] = %q, want %q", id, gotExcluded[id], reason)
+				}
+			}
+		})
+	}
+}
+
+func TestReplacementPoolNeedsAuthor(t *testing.T) {
+	for pool, want := range map[ReplacementPool]bool{
+		ReplacementPoolReviewerTeam: false,
+		ReplacementPoolAuthorTeam:   true,
+		ReplacementPoolUnion:        true,
+	} {
+		if got := pool.needsAuthor(); got != want {
+			t.Errorf("%s.needsAuthor() = %v, want %v", pool, got, want)
+		}
+	}
+}
+
+func TestPullRequestServiceReplacementPoolDefault(t *testing.T) {
+	s := NewPullRequestService(nil, nil, AssignmentConfig{})
+	if got := s.replacementPool(); got != ReplacementPoolReviewerTeam {
+		t.Errorf("default pool = %q, want %q", got, ReplacementPoolReviewerTeam)
+	}
+
+	s = NewPullRequestService(nil, nil, AssignmentConfig{ReplacementPool: ReplacementPoolUnion})
+	if got := s.replacementPool(); got != ReplacementPoolUnion {
+		t.Errorf("pool = %q, want %q", got, ReplacementPoolUnion)
+	}
+}
+
+func rosterUser(t *testing.T, userID string) models.User {
+	t.Helper()
+	i := slices.IndexFunc(poolRoster, func(u models.User) bool { return u.UserID == userID })
+	if i < 0 {
+		t.Fatalf("user %s is not in the roster", userID)
+	}
+	return poolRoster[i]
+}
@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strconv"
+
+	"github.com/Wucop228/avito-PullRequest/internal/events"
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+	"github.com/Wucop228/avito-PullRequest/internal/repo"
+)
+
+var (
+	ErrPRNotOpen           = repo.ErrPullRequestNotOpen
+	ErrReviewerIsAuthor    = errors.New("author cannot review own pull request")
+	ErrReviewerInactive    = errors.New("reviewer is not active")
+	ErrReviewerAssigned    = errors.New("reviewer is already assigned to this PR")
+	ErrInvalidReviewerGoal = errors.New("reviewer count must be positive")
+)
+
+// strategyManual marks explanations of reviewers picked by an admin.
+const strategyManual = "manual"
+
+// AddReviewer assigns userID to an open PR in addition to its reviewers.
+func (s *PullRequestService) AddReviewer(ctx context.Context, prID, userID string) (*models.PullRequest, *models.AssignmentExplanation, error) {
+	ctx, span := tracer.Start(ctx, "PullRequestService.AddReviewer")
+	defer span.End()
+
+	pr, err := s.openPullRequest(ctx, prID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := s.checkNewReviewer(ctx, pr, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	explanation := manualExplanation("add", user, []string{userID})
+	if err := repo.AddPullRequestReviewers(ctx, s.db, prID, []string{userID}, explanation); err != nil {
+		return nil, nil, err
+	}
+	pr.AssignedReviewers = append(pr.AssignedReviewers, userID)
+
+	slog.InfoContext(ctx, "reviewer added", "pull_request_id", prID, "user_id", userID)
+
+	s.publishAssigned(ctx, s.authorTeam(ctx, pr), pr, []string{userID})
+
+	return pr, explanation, nil
+}
+
+// RemoveReviewer unassigns userID from an open PR without a replacement.
+func (s *PullRequestService) RemoveReviewer(ctx context.Context, prID, userID string) (*models.PullRequest, *models.AssignmentExplanation, error) {
+	ctx, span := tracer.Start(ctx, "PullRequestService.RemoveReviewer")
+	defer span.End()
+
+	pr, err := s.openPullRequest(ctx, prID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !slices.Contains(pr.AssignedReviewers, userID) {
+		return nil, nil, ErrReviewerNotAssigned
+	}
+
+	explanation := &models.AssignmentExplanation{
+		Action:        "remove",
+		TeamName:      s.authorTeam(ctx, pr),
+		RemovedUserID: userID,
+		Candidates:    []string{},
+		Excluded:      []models.ExcludedCandidate{},
+		Strategy:      strategyManual,
+		RosterVersion: rosterVersion(nil),
+		Selected:      []string{},
+	}
+	if err := repo.RemovePullRequestReviewer(ctx, s.db, prID, userID, explanation); err != nil {
+		return nil, nil, err
+	}
+	pr.AssignedReviewers = slices.DeleteFunc(pr.AssignedReviewers, func(id string) bool { return id == userID })
+
+	slog.InfoContext(ctx, "reviewer removed", "pull_request_id", prID, "user_id", userID)
+
+	s.publish(ctx, events.New(events.TypeReviewerRemoved, explanation.TeamName, []string{userID}, reviewerRemovedPayload{
+		PR:        pr,
+		RemovedID: userID,
+	}))
+
+	return pr, explanation, nil
+}
+
+// ReassignReviewerTo replaces oldUserID on an open PR with newUserID, picked
+// by an admin instead of at random. With an empty oldUserID the reviewer
+// holding the review longest is replaced; explanation.ReplacedUserID names
+// them.
+func (s *PullRequestService) ReassignReviewerTo(ctx context.Context, prID, oldUserID, newUserID string) (*models.PullRequest, *models.AssignmentExplanation, error) {
+	ctx, span := tracer.Start(ctx, "PullRequestService.ReassignReviewerTo")
+	defer span.End()
+
+	pr, err := s.openPullRequest(ctx, prID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if oldUserID == "" {
+		if oldUserID, err = repo.GetLongestAssignedReviewer(ctx, s.db, prID); err != nil {
+			return nil, nil, err
+		}
+	}
+	if !slices.Contains(pr.AssignedReviewers, oldUserID) {
+		return nil, nil, ErrReviewerNotAssigned
+	}
+
+	user, err := s.checkNewReviewer(ctx, pr, newUserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	explanation := manualExplanation("reassign", user, []string{newUserID})
+	explanation.ReplacedUserID = oldUserID
+	if err := repo.ReplacePullRequestReviewer(ctx, s.db, prID, oldUserID, newUserID, explanation); err != nil {
+		return nil, nil, err
+	}
+	pr.AssignedReviewers[slices.Index(pr.AssignedReviewers, oldUserID)] = newUserID
+
+	slog.InfoContext(ctx, "reviewer reassigned",
+		"pull_request_id", prID,
+		"old_user_id", oldUserID,
+		"new_user_id", newUserID,
+		"reason", strategyManual,
+	)
+
+	s.publish(ctx, events.New(events.TypeReviewerReassigned, s.authorTeam(ctx, pr), []string{oldUserID, newUserID}, reviewerReassignedPayload{
+		PR:         pr,
+		OldUserID:  oldUserID,
+		ReplacedBy: newUserID,
+		Reason:     strategyManual,
+	}))
+
+	return pr, explanation, nil
+}
+
+// TopUpReviewers assigns teammates of the author to an open PR until it has
+// count reviewers, picking them like CreatePullRequest does. It returns the
+// added reviewers, none when the PR already has enough; then the explanation
+// is nil.
+func (s *PullRequestService) TopUpReviewers(ctx context.Context, prID string, count int) (*models.PullRequest, []string, *models.AssignmentExplanation, error) {
+	ctx, span := tracer.Start(ctx, "PullRequestService.TopUpReviewers")
+	defer span.End()
+
+	if count <= 0 {
+		return nil, nil, nil, ErrInvalidReviewerGoal
+	}
+
+	pr, err := s.openPullRequest(ctx, prID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	missing := count - len(pr.AssignedReviewers)
+	if missing <= 0 {
+		return pr, []string{}, nil, nil
+	}
+
+	author, err := repo.GetUserByID(ctx, s.db, pr.AuthorID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if author == nil {
+		return nil, nil, nil, ErrAuthorNotFound
+	}
+
+	teamUsers, err := repo.GetUsersByTeams(ctx, s.db, []string{author.TeamName})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	candidates, excluded := splitCandidates(teamUsers, func(u models.User) string {
+		switch {
+		case u.UserID == pr.AuthorID:
+			return models.ExcludedAuthor
+		case slices.Contains(pr.AssignedReviewers, u.UserID):
+			return models.ExcludedAlreadyAssigned
+		case !u.IsActive:
+			return models.ExcludedInactive
+		}
+		return ""
+	})
+	if len(candidates) == 0 {
+		return nil, nil, nil, ErrNoCandidate
+	}
+
+	subject := []string{"top_up", prID, strconv.Itoa(count)}
+	r, rosterVersion := s.selection.rand(subject, candidates)
+	added := selectRandomReviewers(r, candidates, missing)
+
+	explanation := s.explain(models.AssignmentExplanation{
+		Action:        "top_up",
+		TeamName:      author.TeamName,
+		Candidates:    candidates,
+		Excluded:      excluded,
+		Requested:     missing,
+		Subject:       subject,
+		RosterVersion: rosterVersion,
+		Selected:      added,
+	})
+	if err := repo.AddPullRequestReviewers(ctx, s.db, prID, added, explanation); err != nil {
+		return nil, nil, nil, err
+	}
+	pr.AssignedReviewers = append(pr.AssignedReviewers, added...)
+
+	slog.InfoContext(ctx, "reviewers topped up",
+		"pull_request_id", prID,
+		"reviewers", added,
+		"roster_version", rosterVersion,
+	)
+
+	s.publishAssigned(ctx, author.TeamName, pr, added)
+
+	return pr, added, explanation, nil
+}
+
+// openPullRequest loads a PR that reviewers can be changed on.
+func (s *PullRequestService) openPullRequest(ctx context.Context, prID string) (*models.PullRequest, error) {
+	pr, err := repo.GetPullRequestWithReviewers(ctx, s.db, prID)
+	if err != nil {
+		return nil, err
+	}
+	if pr == nil {
+		return nil, ErrPRNotFound
+	}
+	if pr.Status != "OPEN" {
+		return nil, ErrPRNotOpen
+	}
+	return pr, nil
+}
+
+// checkNewReviewer applies the rules of automatic selection to a reviewer
+// picked by hand: an active user other than the author, not assigned yet.
+// Unlike automatic selection, any team is allowed.
+func (s *PullRequestService) checkNewReviewer(ctx context.Context, pr *models.PullRequest, userID string) (*models.User, error) {
+	user, err := repo.GetUserByID(ctx, s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case user == nil:
+		return nil, ErrUserNotFound
+	case user.UserID == pr.AuthorID:
+		return nil, ErrReviewerIsAuthor
+	case !user.IsActive:
+		return nil, ErrReviewerInactive
+	case slices.Contains(pr.AssignedReviewers, user.UserID):
+		return nil, ErrReviewerAssigned
+	}
+	return user, nil
+}
+
+func (s *PullRequestService) publishAssigned(ctx context.Context, teamName string, pr *models.PullRequest, reviewerIDs []string) {
+	for _, reviewerID := range reviewerIDs {
+		s.publish(ctx, events.New(events.TypeReviewerAssigned, teamName, []string{reviewerID}, reviewerAssignedPayload{
+			PullRequestID: pr.PullRequestID,
+			ReviewerID:    reviewerID,
+		}))
+	}
+}
+
+func manualExplanation(action string, user *models.User, selected []string) *models.AssignmentExplanation {
+	return &models.AssignmentExplanation{
+		Action:        action,
+		TeamName:      user.TeamName,
+		Candidates:    selected,
+		Excluded:      []models.ExcludedCandidate{},
+		Requested:     len(selected),
+		Strategy:      strategyManual,
+		RosterVersion: rosterVersion(selected),
+		Selected:      selected,
+	}
+}
+
+// splitCandidates separates the members that can be picked from those that
+// cannot, for which excludedReason returns why.
+func splitCandidates(members []models.User, excludedReason func(models.User) string) ([]string, []models.ExcludedCandidate) {
+	candidates := make([]string, 0)
+	excluded := make([]models.ExcludedCandidate, 0)
+	for _, u := range members {
+		if reason := excludedReason(u); reason != "" {
+			excluded = append(excluded, models.ExcludedCandidate{UserID: u.UserID, Reason: reason})
+			continue
+		}
+		candidates = append(candidates, u.UserID)
+	}
+	return candidates, excluded
+}
+
+type reviewerRemovedPayload struct {
+	PR        *models.PullRequest `json:"pr"`
+	RemovedID string              `json:"removed_user_id"`
+}
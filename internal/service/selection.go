@@ -0,0 +1,81 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// selection picks reviewers either at random or, with a seed, as a function
+// of the seed, the pull request and the candidates, so that an assignment
+// can be derived again later.
+type selection struct {
+	seed string
+	// random is used without a seed.
+	random *rand.Rand
+}
+
+func newSelection(seed string, src rand.Source) *selection {
+	if src == nil {
+		src = globalSource{}
+	} else {
+		src = &lockedSource{src: src}
+	}
+	return &selection{seed: seed, random: rand.New(src)}
+}
+
+// strategy names how reviewers are picked, for explanations.
+func (s *selection) strategy() string {
+	if s.seed == "" {
+		return "random"
+	}
+	return "seeded"
+}
+
+// rand returns the random numbers for one choice among candidates. subject
+// names the choice, like the PR id, and must differ between choices made for
+// the same PR. The candidates' roster version is returned for the logs.
+func (s *selection) rand(subject []string, candidates []string) (*rand.Rand, string) {
+	version := rosterVersion(candidates)
+	if s.seed == "" {
+		return s.random, version
+	}
+
+	h := sha256.New()
+	for _, part := range append([]string{s.seed, version}, subject...) {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	sum := h.Sum(nil)
+	return rand.New(rand.NewPCG(binary.BigEndian.Uint64(sum[:8]), binary.BigEndian.Uint64(sum[8:16]))), version
+}
+
+// rosterVersion identifies a set of candidates regardless of their order.
+func rosterVersion(ids []string) string {
+	sorted := slices.Clone(ids)
+	slices.Sort(sorted)
+	sum := sha256.Sum256([]byte(strings.Join(sorted, "\x00")))
+	return hex.EncodeToString(sum[:6])
+}
+
+// globalSource draws from the randomly seeded top-level functions of
+// math/rand/v2, which are safe for concurrent use.
+type globalSource struct{}
+
+func (globalSource) Uint64() uint64 { return rand.Uint64() }
+
+// lockedSource makes an injected source safe for concurrent requests.
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source
+}
+
+func (s *lockedSource) Uint64() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Uint64()
+}
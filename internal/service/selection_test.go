@@ -0,0 +1,88 @@
+package service
+
+import (
+	"slices"
+	"testing"
+)
+
+var selectionCandidates = []string{"u1", "u2", "u3", "u4", "u5", "u6", "u7", "u8"}
+
+// selectionPicks runs the choices the service makes for a PR with s: the
+// reviewers on create, preferring code owners, a top-up and a reassignment.
+func selectionPicks(s *PullRequestService, prID string, candidates []string) [][]string {
+	r, _ := s.selection.rand([]string{"create", prID}, candidates)
+	created := selectReviewersPreferring(r, candidates, []string{"u7", "u3"}, 2)
+
+	r, _ = s.selection.rand([]string{"top_up", prID, "3"}, candidates)
+	topUp := selectRandomReviewers(r, candidates, 3)
+
+	sorted := slices.Sorted(slices.Values(candidates))
+	r, _ = s.selection.rand([]string{"reassign", prID, "u1"}, sorted)
+	reassigned := sorted[r.IntN(len(sorted))]
+
+	return [][]string{created, topUp, {reassigned}}
+}
+
+func TestSeededSelectionIsDeterministic(t *testing.T) {
+	tests := []struct {
+		name       string
+		seed       string
+		otherSeed  string
+		prID       string
+		candidates []string
+	}{
+		{
+			name:       "candidates in roster order",
+			seed:       "alpha",
+			otherSeed:  "beta",
+			prID:       "pr-1",
+			candidates: selectionCandidates,
+		},
+		{
+			name:       "candidates in query order",
+			seed:       "alpha",
+			otherSeed:  "beta",
+			prID:       "pr-2",
+			candidates: []string{"u5", "u2", "u8", "u1", "u7", "u3", "u6", "u4"},
+		},
+		{
+			name:       "seeds differing in case",
+			seed:       "release-2025",
+			otherSeed:  "Release-2025",
+			prID:       "pr-3",
+			candidates: selectionCandidates,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := NewPullRequestService(nil, nil, AssignmentConfig{Seed: tt.seed})
+			second := NewPullRequestService(nil, nil, AssignmentConfig{Seed: tt.seed})
+			other := NewPullRequestService(nil, nil, AssignmentConfig{Seed: tt.otherSeed})
+
+			want := selectionPicks(first, tt.prID, tt.candidates)
+			if got := selectionPicks(second, tt.prID, tt.candidates); !slices.EqualFunc(got, want, slices.Equal) {
+				t.Errorf("same seed picked %v, then %v", want, got)
+			}
+
+			shuffled := slices.Clone(tt.candidates)
+			slices.Reverse(shuffled)
+			if got := selectionPicks(second, tt.prID, shuffled); !slices.EqualFunc(got, want, slices.Equal) {
+				t.Errorf("same seed picked %v, with candidates reversed %v", want, got)
+			}
+
+			if got := selectionPicks(other, tt.prID, tt.candidates); slices.EqualFunc(got, want, slices.Equal) {
+				t.Errorf("seeds %q and %q both picked %v", tt.seed, tt.otherSeed, got)
+			}
+		})
+	}
+}
+
+func TestSelectionStrategy(t *testing.T) {
+	if got := NewPullRequestService(nil, nil, AssignmentConfig{}).selection.strategy(); got != "random" {
+		t.Errorf("strategy without seed = %q, want %q", got, "random")
+	}
+	if got := NewPullRequestService(nil, nil, AssignmentConfig{Seed: "alpha"}).selection.strategy(); got != "seeded" {
+		t.Errorf("strategy with seed = %q, want %q", got, "seeded")
+	}
+}
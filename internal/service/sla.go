@@ -0,0 +1,195 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+)
+
+// Defaults for teams that did not configure their own review SLA: one
+// working day of a Monday to Friday, 09:00-18:00 UTC schedule.
+const (
+	defaultReviewSLAHours = 9
+	defaultWorkStartHour  = 9
+	defaultWorkEndHour    = 18
+	defaultTimezone       = "UTC"
+)
+
+var defaultWorkDays = []int{1, 2, 3, 4, 5}
+
+// workCalendar knows which hours of the week count towards a review SLA.
+type workCalendar struct {
+	loc        *time.Location
+	days       [7]bool
+	start, end int
+}
+
+// reviewSLA resolves the team's SLA and calendar, falling back to the
+// defaults for unset fields. It also validates the settings.
+func reviewSLA(settings *models.TeamSettings) (time.Duration, workCalendar, error) {
+	hours := defaultReviewSLAHours
+	if settings.ReviewSLAHours != nil {
+		hours = *settings.ReviewSLAHours
+	}
+	if hours <= 0 {
+		return 0, workCalendar{}, errors.New("review_sla_hours must be positive")
+	}
+
+	cal := workCalendar{start: defaultWorkStartHour, end: defaultWorkEndHour}
+	if settings.WorkStartHour != nil {
+		cal.start = *settings.WorkStartHour
+	}
+	if settings.WorkEndHour != nil {
+		cal.end = *settings.WorkEndHour
+	}
+	if cal.start < 0 || cal.end > 24 || cal.start >= cal.end {
+		return 0, workCalendar{}, errors.New("work hours must satisfy 0 <= work_start_hour < work_end_hour <= 24")
+	}
+
+	days := defaultWorkDays
+	if settings.WorkDays != nil {
+		days = settings.WorkDays
+	}
+	if len(days) == 0 {
+		return 0, workCalendar{}, errors.New("work_days must not be empty")
+	}
+	for _, d := range days {
+		if d < 1 || d > 7 {
+			return 0, workCalendar{}, fmt.Errorf("work_days must be ISO weekdays 1-7, got %d", d)
+		}
+		cal.days[d%7] = true
+	}
+
+	tz := defaultTimezone
+	if settings.Timezone != nil {
+		tz = *settings.Timezone
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return 0, workCalendar{}, fmt.Errorf("unknown timezone %q", tz)
+	}
+	cal.loc = loc
+
+	return time.Duration(hours) * time.Hour, cal, nil
+}
+
+// addWorkTime returns the moment d of working time after t.
+func (c workCalendar) addWorkTime(t time.Time, d time.Duration) time.Time {
+	t = t.In(c.loc)
+	for {
+		y, m, day := t.Date()
+		dayStart := time.Date(y, m, day, c.start, 0, 0, 0, c.loc)
+		dayEnd := time.Date(y, m, day, c.end, 0, 0, 0, c.loc)
+
+		if c.days[t.Weekday()] && t.Before(dayEnd) {
+			if t.Before(dayStart) {
+				t = dayStart
+			}
+			left := dayEnd.Sub(t)
+			if d <= left {
+				return t.Add(d)
+			}
+			d -= left
+		}
+
+		t = time.Date(y, m, day+1, 0, 0, 0, 0, c.loc)
+	}
+}
+
+// evaluateSLA sorts each assignment into met, breached or pending. An
+// assignment meets the SLA when it ended (merge, reassignment or close) by
+// its deadline.
+func evaluateSLA(records []models.ReviewAssignmentRecord, settings map[string]*models.TeamSettings, now time.Time) (*models.ReviewSLAReport, error) {
+	type teamSLA struct {
+		sla time.Duration
+		cal workCalendar
+	}
+	calendars := make(map[string]teamSLA)
+
+	teams := make(map[string]*models.TeamSLAStats)
+	reviewers := make(map[string]*models.ReviewerSLAStats)
+	teamOrder := make([]string, 0)
+	reviewerOrder := make([]string, 0)
+
+	report := &models.ReviewSLAReport{
+		Teams:     make([]models.TeamSLAStats, 0),
+		Reviewers: make([]models.ReviewerSLAStats, 0),
+		Breaches:  make([]models.SLABreach, 0),
+	}
+
+	for _, r := range records {
+		tc, ok := calendars[r.TeamName]
+		if !ok {
+			s := settings[r.TeamName]
+			if s == nil {
+				s = &models.TeamSettings{TeamName: r.TeamName}
+			}
+			sla, cal, err := reviewSLA(s)
+			if err != nil {
+				return nil, fmt.Errorf("team %s: %w", r.TeamName, err)
+			}
+			tc = teamSLA{sla: sla, cal: cal}
+			calendars[r.TeamName] = tc
+		}
+
+		team, ok := teams[r.TeamName]
+		if !ok {
+			team = &models.TeamSLAStats{TeamName: r.TeamName, ReviewSLAHours: int(tc.sla / time.Hour)}
+			teams[r.TeamName] = team
+			teamOrder = append(teamOrder, r.TeamName)
+		}
+		reviewerKey := r.TeamName + "\x00" + r.ReviewerID
+		reviewer, ok := reviewers[reviewerKey]
+		if !ok {
+			reviewer = &models.ReviewerSLAStats{ReviewerID: r.ReviewerID, TeamName: r.TeamName}
+			reviewers[reviewerKey] = reviewer
+			reviewerOrder = append(reviewerOrder, reviewerKey)
+		}
+
+		deadline := tc.cal.addWorkTime(r.AssignedAt, tc.sla)
+		end := now
+		if r.EndedAt != nil {
+			end = *r.EndedAt
+		}
+
+		team.Assignments++
+		reviewer.Assignments++
+		switch {
+		case !end.After(deadline) && r.EndedAt != nil:
+			team.Met++
+			reviewer.Met++
+		case !end.After(deadline):
+			team.Pending++
+			reviewer.Pending++
+		default:
+			team.Breached++
+			reviewer.Breached++
+			report.Breaches = append(report.Breaches, models.SLABreach{
+				ReviewAssignmentRecord: r,
+				Deadline:               deadline.UTC(),
+				OverdueSeconds:         end.Sub(deadline).Seconds(),
+			})
+		}
+	}
+
+	sort.Strings(teamOrder)
+	for _, name := range teamOrder {
+		report.Teams = append(report.Teams, *teams[name])
+	}
+
+	for _, key := range reviewerOrder {
+		report.Reviewers = append(report.Reviewers, *reviewers[key])
+	}
+	sort.SliceStable(report.Reviewers, func(i, j int) bool {
+		a, b := report.Reviewers[i], report.Reviewers[j]
+		if a.Breached != b.Breached {
+			return a.Breached > b.Breached
+		}
+		return a.ReviewerID < b.ReviewerID
+	})
+
+	return report, nil
+}
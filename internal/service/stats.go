@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+	"github.com/Wucop228/avito-PullRequest/internal/repo"
+)
+
+var (
+	ErrInvalidWindow = errors.New("from must be before to")
+)
+
+type StatsService struct {
+	db *sql.DB
+}
+
+func NewStatsService(db *sql.DB) *StatsService {
+	return &StatsService{db: db}
+}
+
+func (s *StatsService) GetReviewStats(ctx context.Context) (*models.ReviewStats, error) {
+	ctx, span := tracer.Start(ctx, "StatsService.GetReviewStats")
+	defer span.End()
+
+	counts, err := repo.CountPullRequestsByStatus(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	load, err := repo.GetReviewerLoad(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ReviewStats{
+		PullRequests: *counts,
+		Reviewers:    load,
+	}, nil
+}
+
+// GetPullRequestStats reports time-to-merge for PRs merged within [from, to).
+func (s *StatsService) GetPullRequestStats(ctx context.Context, from, to time.Time) (*models.PullRequestStats, error) {
+	ctx, span := tracer.Start(ctx, "StatsService.GetPullRequestStats")
+	defer span.End()
+
+	if !from.Before(to) {
+		return nil, ErrInvalidWindow
+	}
+
+	teams, err := repo.GetTeamMergeStats(ctx, s.db, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	authors, err := repo.GetAuthorMergeStats(ctx, s.db, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	reviewers, err := repo.GetReviewerMergeStats(ctx, s.db, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PullRequestStats{
+		From:      from,
+		To:        to,
+		Teams:     teams,
+		Authors:   authors,
+		Reviewers: reviewers,
+	}, nil
+}
+
+// GetReviewSLAReport checks assignments made within [from, to) against the
+// review SLA of the PR author's team. teamName limits the report to one team.
+func (s *StatsService) GetReviewSLAReport(ctx context.Context, from, to time.Time, teamName string) (*models.ReviewSLAReport, error) {
+	ctx, span := tracer.Start(ctx, "StatsService.GetReviewSLAReport")
+	defer span.End()
+
+	if !from.Before(to) {
+		return nil, ErrInvalidWindow
+	}
+
+	if teamName != "" {
+		team, err := repo.GetTeamByName(ctx, s.db, teamName)
+		if err != nil {
+			return nil, err
+		}
+		if team == nil {
+			return nil, ErrTeamNotFound
+		}
+	}
+
+	settings, err := repo.GetAllTeamSettings(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := repo.GetReviewAssignments(ctx, s.db, from, to, teamName)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := evaluateSLA(records, settings, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	report.From = from
+	report.To = to
+	return report, nil
+}
@@ -1,8 +1,11 @@
 package service
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"log/slog"
 
 	"github.com/Wucop228/avito-PullRequest/internal/models"
 	"github.com/Wucop228/avito-PullRequest/internal/repo"
@@ -11,6 +14,8 @@ import (
 var (
 	ErrTeamExists   = errors.New("team_name already exists")
 	ErrTeamNotFound = errors.New("team_name not found")
+	ErrBadPattern   = errors.New("invalid code owners pattern")
+	ErrBadSettings  = errors.New("invalid team settings")
 )
 
 type TeamService struct {
@@ -21,8 +26,11 @@ func NewTeamService(db *sql.DB) *TeamService {
 	return &TeamService{db: db}
 }
 
-func (s *TeamService) CreateTeamWithMembers(req *models.RequestTeamAdd) error {
-	team, err := repo.GetTeamByName(s.db, req.TeamName)
+func (s *TeamService) CreateTeamWithMembers(ctx context.Context, req *models.RequestTeamAdd) error {
+	ctx, span := tracer.Start(ctx, "TeamService.CreateTeamWithMembers")
+	defer span.End()
+
+	team, err := repo.GetTeamByName(ctx, s.db, req.TeamName)
 	if err != nil {
 		return err
 	}
@@ -31,15 +39,20 @@ func (s *TeamService) CreateTeamWithMembers(req *models.RequestTeamAdd) error {
 		return ErrTeamExists
 	}
 
-	if err := repo.CreateTeamWithMembers(s.db, req); err != nil {
+	if err := repo.CreateTeamWithMembers(ctx, s.db, req); err != nil {
 		return err
 	}
 
+	slog.InfoContext(ctx, "team created", "team_name", req.TeamName, "members", len(req.Members))
+
 	return nil
 }
 
-func (s *TeamService) GetTeam(name string) (*models.RequestTeamAdd, error) {
-	team, err := repo.GetTeamWithMembers(s.db, name)
+func (s *TeamService) GetTeam(ctx context.Context, name string) (*models.RequestTeamAdd, error) {
+	ctx, span := tracer.Start(ctx, "TeamService.GetTeam")
+	defer span.End()
+
+	team, err := repo.GetTeamWithMembers(ctx, s.db, name)
 	if err != nil {
 		return nil, err
 	}
@@ -48,3 +61,115 @@ func (s *TeamService) GetTeam(name string) (*models.RequestTeamAdd, error) {
 	}
 	return team, nil
 }
+
+// SetCodeOwners replaces the team's CODEOWNERS-style rules. Later rules take
+// precedence over earlier ones for the same file.
+func (s *TeamService) SetCodeOwners(ctx context.Context, req *models.RequestTeamSetCodeOwners) error {
+	ctx, span := tracer.Start(ctx, "TeamService.SetCodeOwners")
+	defer span.End()
+
+	team, err := repo.GetTeamByName(ctx, s.db, req.TeamName)
+	if err != nil {
+		return err
+	}
+	if team == nil {
+		return ErrTeamNotFound
+	}
+
+	for i, rule := range req.Rules {
+		if rule.Owners == nil {
+			req.Rules[i].Owners = []string{}
+		}
+		if !validCodeOwnerPattern(rule.Pattern) {
+			return fmt.Errorf("%w: %q", ErrBadPattern, rule.Pattern)
+		}
+		for _, owner := range rule.Owners {
+			user, err := repo.GetUserByID(ctx, s.db, owner)
+			if err != nil {
+				return err
+			}
+			if user == nil {
+				return fmt.Errorf("%w: %s", ErrUserNotFound, owner)
+			}
+		}
+	}
+
+	if err := repo.ReplaceTeamCodeOwners(ctx, s.db, req.TeamName, req.Rules); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "team code owners set", "team_name", req.TeamName, "rules", len(req.Rules))
+
+	return nil
+}
+
+func (s *TeamService) GetCodeOwners(ctx context.Context, name string) (*models.RequestTeamSetCodeOwners, error) {
+	ctx, span := tracer.Start(ctx, "TeamService.GetCodeOwners")
+	defer span.End()
+
+	team, err := repo.GetTeamByName(ctx, s.db, name)
+	if err != nil {
+		return nil, err
+	}
+	if team == nil {
+		return nil, ErrTeamNotFound
+	}
+
+	rules, err := repo.GetTeamCodeOwners(ctx, s.db, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.RequestTeamSetCodeOwners{
+		TeamName: name,
+		Rules:    rules,
+	}, nil
+}
+
+// SetSettings replaces the team's settings; omitted fields reset to the
+// service-wide defaults.
+func (s *TeamService) SetSettings(ctx context.Context, settings *models.TeamSettings) error {
+	ctx, span := tracer.Start(ctx, "TeamService.SetSettings")
+	defer span.End()
+
+	team, err := repo.GetTeamByName(ctx, s.db, settings.TeamName)
+	if err != nil {
+		return err
+	}
+	if team == nil {
+		return ErrTeamNotFound
+	}
+
+	if h := settings.StalePRThresholdHours; h != nil && *h <= 0 {
+		return fmt.Errorf("%w: stale_pr_threshold_hours must be positive", ErrBadSettings)
+	}
+	if q := settings.MonthlyDeclineQuota; q != nil && *q < 0 {
+		return fmt.Errorf("%w: monthly_decline_quota must not be negative", ErrBadSettings)
+	}
+	if _, _, err := reviewSLA(settings); err != nil {
+		return fmt.Errorf("%w: %v", ErrBadSettings, err)
+	}
+
+	if err := repo.UpsertTeamSettings(ctx, s.db, settings); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "team settings set", "team_name", settings.TeamName)
+
+	return nil
+}
+
+func (s *TeamService) GetSettings(ctx context.Context, name string) (*models.TeamSettings, error) {
+	ctx, span := tracer.Start(ctx, "TeamService.GetSettings")
+	defer span.End()
+
+	team, err := repo.GetTeamByName(ctx, s.db, name)
+	if err != nil {
+		return nil, err
+	}
+	if team == nil {
+		return nil, ErrTeamNotFound
+	}
+
+	return repo.GetTeamSettings(ctx, s.db, name)
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/Wucop228/avito-PullRequest/internal/models"
+	"github.com/Wucop228/avito-PullRequest/internal/repo"
+)
+
+var ErrInvalidToken = errors.New("invalid or revoked API token")
+
+// tokenPrefix marks API tokens of the service, so that a leaked one is easy
+// to recognize.
+const tokenPrefix = "prt_"
+
+// IssueToken creates an API token a user authenticates with. Only its hash
+// is stored, so the token cannot be shown again.
+func (s *UserService) IssueToken(ctx context.Context, userID string) (string, error) {
+	ctx, span := tracer.Start(ctx, "UserService.IssueToken")
+	defer span.End()
+
+	user, err := repo.GetUserByID(ctx, s.db, userID)
+	if err != nil {
+		return "", err
+	}
+	if user == nil {
+		return "", ErrUserNotFound
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	token := tokenPrefix + hex.EncodeToString(secret)
+
+	if err := repo.InsertUserToken(ctx, s.db, hashToken(token), userID); err != nil {
+		return "", err
+	}
+
+	slog.InfoContext(ctx, "api token issued", "user_id", userID)
+
+	return token, nil
+}
+
+// RevokeTokens revokes every API token of the user and returns how many
+// there were.
+func (s *UserService) RevokeTokens(ctx context.Context, userID string) (int64, error) {
+	ctx, span := tracer.Start(ctx, "UserService.RevokeTokens")
+	defer span.End()
+
+	n, err := repo.DeleteUserTokens(ctx, s.db, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	slog.InfoContext(ctx, "api tokens revoked", "user_id", userID, "count", n)
+
+	return n, nil
+}
+
+// Authenticate returns the user the API token was issued to. Tokens of
+// deactivated users are refused.
+func (s *UserService) Authenticate(ctx context.Context, token string) (*models.User, error) {
+	ctx, span := tracer.Start(ctx, "UserService.Authenticate")
+	defer span.End()
+
+	if !strings.HasPrefix(token, tokenPrefix) {
+		return nil, ErrInvalidToken
+	}
+
+	user, err := repo.GetUserByTokenHash(ctx, s.db, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.IsActive {
+		return nil, ErrInvalidToken
+	}
+
+	return user, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,15 +1,25 @@
 package service
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"log/slog"
+	"strings"
 
 	"github.com/Wucop228/avito-PullRequest/internal/models"
 	"github.com/Wucop228/avito-PullRequest/internal/repo"
 )
 
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+)
+
 var (
-	ErrUserNotFound = errors.New("user not found")
+	ErrUserNotFound         = errors.New("user not found")
+	ErrUnknownProvider      = errors.New("unknown external provider")
+	ErrExternalLoginUnknown = errors.New("external login is not mapped to a user")
 )
 
 type UserService struct {
@@ -20,13 +30,73 @@ func NewUserService(db *sql.DB) *UserService {
 	return &UserService{db: db}
 }
 
-func (s *UserService) SetIsActive(userID string, isActive bool) (*models.User, error) {
-	user, err := repo.UpdateUserIsActive(s.db, userID, isActive)
+func (s *UserService) SetIsActive(ctx context.Context, userID string, isActive bool) (*models.User, error) {
+	ctx, span := tracer.Start(ctx, "UserService.SetIsActive")
+	defer span.End()
+
+	user, err := repo.UpdateUserIsActive(ctx, s.db, userID, isActive)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
+
+	slog.InfoContext(ctx, "user activity changed", "user_id", userID, "is_active", isActive)
+
 	return user, nil
 }
+
+func (s *UserService) SetExternalLogin(ctx context.Context, account *models.ExternalAccount) error {
+	ctx, span := tracer.Start(ctx, "UserService.SetExternalLogin")
+	defer span.End()
+
+	if !isKnownProvider(account.Provider) {
+		return ErrUnknownProvider
+	}
+
+	user, err := repo.GetUserByID(ctx, s.db, account.UserID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+
+	account.Login = normalizeLogin(account.Login)
+	if err := repo.UpsertExternalAccount(ctx, s.db, account); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "external login set",
+		"user_id", account.UserID,
+		"provider", account.Provider,
+		"login", account.Login,
+	)
+
+	return nil
+}
+
+func resolveExternalLogin(ctx context.Context, db *sql.DB, provider, login string) (string, error) {
+	userID, err := repo.GetUserIDByExternalLogin(ctx, db, provider, normalizeLogin(login))
+	if err != nil {
+		return "", err
+	}
+	if userID == "" {
+		return "", ErrExternalLoginUnknown
+	}
+	return userID, nil
+}
+
+func isKnownProvider(provider string) bool {
+	switch provider {
+	case ProviderGitHub, ProviderGitLab:
+		return true
+	}
+	return false
+}
+
+// Logins on code hosting platforms are case-insensitive.
+func normalizeLogin(login string) string {
+	return strings.ToLower(strings.TrimSpace(login))
+}
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS external_accounts;
\ No newline at end of file
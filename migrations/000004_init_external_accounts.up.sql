@@ -0,0 +1,9 @@
+CREATE TABLE external_accounts (
+    provider TEXT NOT NULL,
+    login    TEXT NOT NULL,
+    user_id  TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (provider, login)
+);
+
+CREATE INDEX idx_external_accounts_user
+    ON external_accounts (user_id);
\ No newline at end of file
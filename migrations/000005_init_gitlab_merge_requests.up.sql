@@ -0,0 +1,10 @@
+ALTER TABLE pull_requests DROP CONSTRAINT pull_requests_status_check;
+ALTER TABLE pull_requests
+    ADD CONSTRAINT pull_requests_status_check CHECK (status IN ('OPEN', 'MERGED', 'CLOSED'));
+
+CREATE TABLE gitlab_merge_requests (
+    project_id      BIGINT NOT NULL,
+    mr_iid          BIGINT NOT NULL,
+    pull_request_id TEXT   NOT NULL UNIQUE REFERENCES pull_requests(id) ON DELETE CASCADE,
+    PRIMARY KEY (project_id, mr_iid)
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS team_code_owners;
\ No newline at end of file
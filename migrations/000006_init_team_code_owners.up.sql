@@ -0,0 +1,7 @@
+CREATE TABLE team_code_owners (
+    team_name TEXT   NOT NULL REFERENCES teams(name) ON DELETE CASCADE,
+    position  INT    NOT NULL,
+    pattern   TEXT   NOT NULL,
+    owners    TEXT[] NOT NULL,
+    PRIMARY KEY (team_name, position)
+);
\ No newline at end of file
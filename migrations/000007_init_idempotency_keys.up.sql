@@ -0,0 +1,15 @@
+CREATE TABLE idempotency_keys (
+    key          TEXT        NOT NULL,
+    method       TEXT        NOT NULL,
+    path         TEXT        NOT NULL,
+    request_hash TEXT        NOT NULL,
+    status_code  INT,
+    content_type TEXT,
+    response     BYTEA,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at   TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (key, method, path)
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at
+    ON idempotency_keys (expires_at);
\ No newline at end of file
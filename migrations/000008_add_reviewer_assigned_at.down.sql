@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_pull_requests_author_merged_at;
+DROP INDEX IF EXISTS idx_pull_requests_merged_at;
+
+ALTER TABLE pull_request_reviewers DROP COLUMN IF EXISTS assigned_at;
\ No newline at end of file
@@ -0,0 +1,15 @@
+ALTER TABLE pull_request_reviewers
+    ADD COLUMN assigned_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+UPDATE pull_request_reviewers r
+SET assigned_at = pr.created_at
+FROM pull_requests pr
+WHERE pr.id = r.pull_request_id;
+
+CREATE INDEX idx_pull_requests_merged_at
+    ON pull_requests (merged_at)
+    WHERE status = 'MERGED';
+
+CREATE INDEX idx_pull_requests_author_merged_at
+    ON pull_requests (author_id, merged_at)
+    WHERE status = 'MERGED';
\ No newline at end of file
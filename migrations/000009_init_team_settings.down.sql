@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_pull_requests_open_created_at;
+
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS stale_notified_at;
+
+DROP TABLE IF EXISTS team_settings;
\ No newline at end of file
@@ -0,0 +1,10 @@
+CREATE TABLE team_settings (
+    team_name                TEXT PRIMARY KEY REFERENCES teams(name) ON DELETE CASCADE,
+    stale_pr_threshold_hours INT CHECK (stale_pr_threshold_hours > 0)
+);
+
+ALTER TABLE pull_requests ADD COLUMN stale_notified_at TIMESTAMPTZ;
+
+CREATE INDEX idx_pull_requests_open_created_at
+    ON pull_requests (created_at)
+    WHERE status = 'OPEN';
\ No newline at end of file
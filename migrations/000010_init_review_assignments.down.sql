@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS review_assignments;
+
+ALTER TABLE team_settings
+    DROP COLUMN IF EXISTS review_sla_hours,
+    DROP COLUMN IF EXISTS work_days,
+    DROP COLUMN IF EXISTS work_start_hour,
+    DROP COLUMN IF EXISTS work_end_hour,
+    DROP COLUMN IF EXISTS timezone;
@@ -0,0 +1,41 @@
+ALTER TABLE team_settings
+    ADD COLUMN review_sla_hours INT CHECK (review_sla_hours > 0),
+    ADD COLUMN work_days        INT[],
+    ADD COLUMN work_start_hour  INT CHECK (work_start_hour BETWEEN 0 AND 23),
+    ADD COLUMN work_end_hour    INT CHECK (work_end_hour BETWEEN 1 AND 24),
+    ADD COLUMN timezone         TEXT;
+
+CREATE TABLE review_assignments (
+    id              BIGSERIAL PRIMARY KEY,
+    pull_request_id TEXT NOT NULL REFERENCES pull_requests(id) ON DELETE CASCADE,
+    reviewer_id     TEXT NOT NULL REFERENCES users(id),
+    assigned_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ended_at        TIMESTAMPTZ,
+    end_reason      TEXT CHECK (end_reason IN ('merged', 'reassigned', 'closed'))
+);
+
+CREATE INDEX idx_review_assignments_assigned_at
+    ON review_assignments (assigned_at);
+
+CREATE UNIQUE INDEX idx_review_assignments_active
+    ON review_assignments (pull_request_id, reviewer_id)
+    WHERE ended_at IS NULL;
+
+-- Replaced reviewers were never recorded, so the history starts from the
+-- current assignments. Close time was not recorded either: assignments of
+-- closed PRs count as ended right away and never show up as breaches.
+INSERT INTO review_assignments (pull_request_id, reviewer_id, assigned_at, ended_at, end_reason)
+SELECT
+    r.pull_request_id,
+    r.reviewer_id,
+    r.assigned_at,
+    CASE pr.status
+        WHEN 'MERGED' THEN pr.merged_at
+        WHEN 'CLOSED' THEN r.assigned_at
+    END,
+    CASE pr.status
+        WHEN 'MERGED' THEN 'merged'
+        WHEN 'CLOSED' THEN 'closed'
+    END
+FROM pull_request_reviewers r
+JOIN pull_requests pr ON pr.id = r.pull_request_id;
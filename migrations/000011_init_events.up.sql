@@ -0,0 +1,11 @@
+CREATE TABLE events (
+    id          BIGSERIAL PRIMARY KEY,
+    type        TEXT NOT NULL,
+    team_name   TEXT,
+    user_ids    TEXT[] NOT NULL DEFAULT '{}',
+    payload     JSONB NOT NULL,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_events_occurred_at
+    ON events (occurred_at);
@@ -0,0 +1,9 @@
+CREATE TABLE rate_limit_buckets (
+    key        TEXT             PRIMARY KEY,
+    tokens     DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMPTZ      NOT NULL,
+    full_at    TIMESTAMPTZ      NOT NULL
+);
+
+CREATE INDEX idx_rate_limit_buckets_full_at
+    ON rate_limit_buckets (full_at);
@@ -0,0 +1,10 @@
+CREATE TABLE assignment_explanations (
+    id              BIGSERIAL PRIMARY KEY,
+    pull_request_id TEXT        NOT NULL REFERENCES pull_requests(id) ON DELETE CASCADE,
+    action          TEXT        NOT NULL CHECK (action IN ('create', 'reassign')),
+    explanation     JSONB       NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_assignment_explanations_pull_request_id
+    ON assignment_explanations (pull_request_id);
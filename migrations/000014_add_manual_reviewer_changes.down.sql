@@ -0,0 +1,11 @@
+DELETE FROM assignment_explanations WHERE action IN ('add', 'remove', 'top_up');
+ALTER TABLE assignment_explanations DROP CONSTRAINT assignment_explanations_action_check;
+ALTER TABLE assignment_explanations
+    ADD CONSTRAINT assignment_explanations_action_check
+    CHECK (action IN ('create', 'reassign'));
+
+UPDATE review_assignments SET end_reason = 'reassigned' WHERE end_reason = 'removed';
+ALTER TABLE review_assignments DROP CONSTRAINT review_assignments_end_reason_check;
+ALTER TABLE review_assignments
+    ADD CONSTRAINT review_assignments_end_reason_check
+    CHECK (end_reason IN ('merged', 'reassigned', 'closed'));
@@ -0,0 +1,12 @@
+DROP INDEX idx_review_assignments_declined;
+
+UPDATE review_assignments SET end_reason = 'reassigned' WHERE end_reason = 'declined';
+ALTER TABLE review_assignments DROP CONSTRAINT review_assignments_end_reason_check;
+ALTER TABLE review_assignments
+    ADD CONSTRAINT review_assignments_end_reason_check
+    CHECK (end_reason IN ('merged', 'reassigned', 'closed', 'removed'));
+ALTER TABLE review_assignments DROP COLUMN decline_reason;
+
+ALTER TABLE team_settings DROP COLUMN monthly_decline_quota;
+
+DROP TABLE user_tokens;
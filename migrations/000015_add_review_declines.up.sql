@@ -0,0 +1,20 @@
+CREATE TABLE user_tokens (
+    token_hash TEXT        PRIMARY KEY,
+    user_id    TEXT        NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_user_tokens_user_id ON user_tokens (user_id);
+
+ALTER TABLE team_settings
+    ADD COLUMN monthly_decline_quota INT CHECK (monthly_decline_quota >= 0);
+
+ALTER TABLE review_assignments ADD COLUMN decline_reason TEXT;
+ALTER TABLE review_assignments DROP CONSTRAINT review_assignments_end_reason_check;
+ALTER TABLE review_assignments
+    ADD CONSTRAINT review_assignments_end_reason_check
+    CHECK (end_reason IN ('merged', 'reassigned', 'closed', 'removed', 'declined'));
+
+CREATE INDEX idx_review_assignments_declined
+    ON review_assignments (reviewer_id, ended_at)
+    WHERE end_reason = 'declined';